@@ -0,0 +1,236 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fritzr/advent2020/logging"
+	"github.com/fritzr/advent2020/registry"
+	"github.com/fritzr/advent2020/util"
+)
+
+// Parse a selection of days: "all", a single day "N", or a range "M-N".
+//
+// Returns the registered puzzles in the selection ordered by day, and whether
+// the selection names more than a single day.
+func parseDays(spec string) ([]*registry.Puzzle, bool, error) {
+	if spec == "all" {
+		return registry.All(), true, nil
+	}
+
+	bounds := strings.SplitN(spec, "-", 2)
+	first, err := strconv.Atoi(bounds[0])
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid day '%s'", spec)
+	}
+	if len(bounds) == 1 {
+		puzzle, err := registry.Lookup(first)
+		if err != nil {
+			return nil, false, err
+		}
+		return []*registry.Puzzle{puzzle}, false, nil
+	}
+
+	last, err := strconv.Atoi(bounds[1])
+	if err != nil || last < first {
+		return nil, false, fmt.Errorf("invalid day range '%s'", spec)
+	}
+	puzzles := make([]*registry.Puzzle, 0, last-first+1)
+	for _, puzzle := range registry.All() {
+		if puzzle.Day >= first && puzzle.Day <= last {
+			puzzles = append(puzzles, puzzle)
+		}
+	}
+	if len(puzzles) == 0 {
+		return nil, true, fmt.Errorf("no puzzles registered in '%s'", spec)
+	}
+	return puzzles, true, nil
+}
+
+// Outcome of running one day in a multi-day run.
+type dayRun struct {
+	puzzle  *registry.Puzzle
+	run     *registry.Run
+	err     error
+	elapsed time.Duration
+}
+
+// Prepare to run a day with the options given on the command line, logging to
+// a sub-logger of log.
+func newRun(puzzle *registry.Puzzle, path string, data []byte,
+	args []string, log *logging.Logger) *registry.Run {
+	run := puzzle.NewRun(path, data)
+	run.Log = log.Sub(puzzle.Name())
+	run.Args = args
+	run.Part = part
+	return run
+}
+
+// Run a single day, subject to --timeout.
+func runDay(puzzle *registry.Puzzle, path string, args []string,
+	log *logging.Logger) dayRun {
+	data, err := registry.ReadInput(path)
+	run := newRun(puzzle, path, data, args, log)
+	if err == nil {
+		ctx, cancel := dayContext()
+		err = run.Solve(ctx)
+		cancel()
+	}
+	return dayRun{puzzle, run, err, run.Elapsed}
+}
+
+// Run one of several days against its default input, or its example with
+// --example.
+func runOneOf(puzzle *registry.Puzzle, log *logging.Logger) dayRun {
+	if !puzzle.Implemented() {
+		return dayRun{puzzle: puzzle}
+	}
+	// Once interrupted, skip the remaining days.
+	if err := runCtx.Err(); err != nil {
+		run := newRun(puzzle, puzzle.Input, nil, nil, log)
+		return dayRun{puzzle, run, err, 0}
+	}
+	if example > 0 {
+		return runExample(puzzle, example, nil, log)
+	}
+	return runDay(puzzle, puzzle.Input, nil, log)
+}
+
+// Run several days against their default inputs, up to --jobs at a time.
+//
+// With --example, run the selected example of each day instead, skipping the
+// days which do not have it.
+//
+// When running days in parallel, the log of each day is buffered and written
+// to stderr once that day and every day before it are done, so the logs of
+// different days are never interleaved.
+func runDays(puzzles []*registry.Puzzle) []dayRun {
+	selected := make([]*registry.Puzzle, 0, len(puzzles))
+	for _, puzzle := range puzzles {
+		if example == 0 || example <= len(puzzle.Examples) {
+			selected = append(selected, puzzle)
+		}
+	}
+	runs := make([]dayRun, len(selected))
+	if jobs <= 1 {
+		for index, puzzle := range selected {
+			runs[index] = runOneOf(puzzle, logger)
+		}
+		return runs
+	}
+
+	logs := make([]bytes.Buffer, len(selected))
+	done := make([]chan struct{}, len(selected))
+	for index := range done {
+		done[index] = make(chan struct{})
+	}
+	next := make(chan int)
+	for worker := 0; worker < jobs; worker++ {
+		go func() {
+			for index := range next {
+				log := logger.WithOutput(&logs[index])
+				runs[index] = runOneOf(selected[index], log)
+				close(done[index])
+			}
+		}()
+	}
+	go func() {
+		for index := range selected {
+			next <- index
+		}
+		close(next)
+	}()
+
+	for index := range selected {
+		<-done[index]
+		os.Stderr.Write(logs[index].Bytes())
+	}
+	return runs
+}
+
+// The answer to a part for the summary table.
+func partAnswer(d *dayRun, part int) string {
+	if d.run != nil {
+		if result := d.run.Result(part); result != nil {
+			return result.Answer()
+		}
+	}
+	if part > d.puzzle.Parts || (d.run != nil && !d.run.Want(part)) {
+		return "-"
+	}
+	return "?"
+}
+
+// With --snippet, the line of the input where a day failed to parse, marked
+// with carets. Otherwise "".
+func errorSnippet(d *dayRun) string {
+	var parseErr *util.ParseError
+	if !snippet || d.run == nil || !errors.As(d.err, &parseErr) {
+		return ""
+	}
+	return parseErr.Snippet(d.run.Data)
+}
+
+// Print a table summarizing the answers and runtimes of each day.
+//
+// Returns the number of days which failed.
+func printSummary(runs []dayRun) int {
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(w, "Day\tTitle\tPart 1\tPart 2\tTime")
+	var total time.Duration
+	failures := 0
+	for index := range runs {
+		d := &runs[index]
+		if d.run == nil {
+			fmt.Fprintf(w, "%d\t%s\t-\t-\t(%s)\n",
+				d.puzzle.Day, d.puzzle.Title, d.puzzle.Status)
+			continue
+		}
+		total += d.elapsed
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\t%v\n", d.puzzle.Day, d.puzzle.Title,
+			partAnswer(d, 1), partAnswer(d, 2), d.elapsed.Round(time.Microsecond))
+		if d.err != nil {
+			failures++
+		}
+	}
+	fmt.Fprintf(w, "\t\t\t\t%v\n", total.Round(time.Microsecond))
+	w.Flush()
+
+	for _, d := range runs {
+		if d.err != nil {
+			fmt.Fprintf(os.Stderr, "day %d: %v\n", d.puzzle.Day, d.err)
+			fmt.Fprint(os.Stderr, errorSnippet(&d))
+		}
+	}
+	return failures
+}
+
+// Run every selected day and summarize the results.
+func runAll(puzzles []*registry.Puzzle, args []string) error {
+	if len(inputs) > 0 {
+		return errors.New("-i cannot be used when running several days")
+	}
+	if len(args) > 0 {
+		return errors.New("puzzle arguments cannot be used when running several days")
+	}
+	runs := runDays(puzzles)
+	failures := 0
+	if format == "text" {
+		failures = printSummary(runs)
+	} else {
+		var err error
+		if failures, err = writeResults(os.Stdout, format, runs); err != nil {
+			return err
+		}
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d of %d days failed", failures, len(puzzles))
+	}
+	return nil
+}
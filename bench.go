@@ -0,0 +1,190 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sort"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	"github.com/fritzr/advent2020/registry"
+)
+
+// Statistics for one phase of a day over several benchmark runs.
+type benchStats struct {
+	Day    int           `json:"day"`
+	Phase  string        `json:"phase"` // "parse", "part N" or "total"
+	Runs   int           `json:"runs"`
+	Min    time.Duration `json:"min_ns"`
+	Median time.Duration `json:"median_ns"`
+	P95    time.Duration `json:"p95_ns"`
+	Max    time.Duration `json:"max_ns"`
+
+	// Mean allocations per run. Only measured for the "total" phase.
+	Allocs uint64 `json:"allocs,omitempty"`
+	Bytes  uint64 `json:"bytes,omitempty"`
+}
+
+// Nearest-rank percentile of sorted durations.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func newBenchStats(day int, phase string, samples []time.Duration) benchStats {
+	sorted := make([]time.Duration, len(samples))
+	copy(sorted, samples)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return benchStats{
+		Day:    day,
+		Phase:  phase,
+		Runs:   len(sorted),
+		Min:    sorted[0],
+		Median: percentile(sorted, 50),
+		P95:    percentile(sorted, 95),
+		Max:    sorted[len(sorted)-1],
+	}
+}
+
+// Run a day n times and collect statistics for each phase.
+//
+// The input is read once up front, so file I/O is not measured.
+func benchDay(puzzle *registry.Puzzle, path string, args []string, n int) (
+	[]benchStats, error) {
+	data, err := registry.ReadInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	phases := make([]string, 0, puzzle.Parts+2)
+	samples := make(map[string][]time.Duration, puzzle.Parts+2)
+	record := func(phase string, elapsed time.Duration) {
+		if _, ok := samples[phase]; !ok {
+			phases = append(phases, phase)
+		}
+		samples[phase] = append(samples[phase], elapsed)
+	}
+
+	var before, after runtime.MemStats
+	var allocs, bytes uint64
+	for iteration := 0; iteration < n; iteration++ {
+		run := newRun(puzzle, path, data, args, logger)
+		ctx, cancel := dayContext()
+		runtime.ReadMemStats(&before)
+		err := run.Solve(ctx)
+		runtime.ReadMemStats(&after)
+		cancel()
+		if err != nil {
+			return nil, fmt.Errorf("day %d: %v", puzzle.Day, err)
+		}
+		allocs += after.Mallocs - before.Mallocs
+		bytes += after.TotalAlloc - before.TotalAlloc
+
+		record("parse", run.ParseTime)
+		for _, result := range run.Results {
+			record(fmt.Sprintf("part %d", result.Part), result.Elapsed)
+		}
+		record("total", run.Elapsed)
+	}
+
+	stats := make([]benchStats, 0, len(phases))
+	for _, phase := range phases {
+		stats = append(stats, newBenchStats(puzzle.Day, phase, samples[phase]))
+	}
+	total := &stats[len(stats)-1]
+	total.Allocs = allocs / uint64(n)
+	total.Bytes = bytes / uint64(n)
+	return stats, nil
+}
+
+func writeBenchText(w io.Writer, stats []benchStats) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "Day\tPhase\tRuns\tMin\tMedian\tp95\tMax\tAllocs/run\tBytes/run\t")
+	round := func(d time.Duration) time.Duration { return d.Round(time.Microsecond) }
+	for _, s := range stats {
+		allocs, bytes := "-", "-"
+		if s.Phase == "total" {
+			allocs = strconv.FormatUint(s.Allocs, 10)
+			bytes = strconv.FormatUint(s.Bytes, 10)
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%v\t%v\t%v\t%v\t%s\t%s\t\n", s.Day, s.Phase,
+			s.Runs, round(s.Min), round(s.Median), round(s.P95), round(s.Max),
+			allocs, bytes)
+	}
+	return tw.Flush()
+}
+
+func writeBenchCSV(w io.Writer, stats []benchStats) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"day", "phase", "runs", "min_ns", "median_ns", "p95_ns",
+		"max_ns", "allocs", "bytes"})
+	ns := func(d time.Duration) string { return strconv.FormatInt(int64(d), 10) }
+	for _, s := range stats {
+		cw.Write([]string{strconv.Itoa(s.Day), s.Phase, strconv.Itoa(s.Runs),
+			ns(s.Min), ns(s.Median), ns(s.P95), ns(s.Max),
+			strconv.FormatUint(s.Allocs, 10), strconv.FormatUint(s.Bytes, 10)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func writeBenchJSON(w io.Writer, stats []benchStats) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stats)
+}
+
+// Benchmark the selected days, running each one benchRuns times.
+//
+// A single day uses the -i input and puzzle arguments if given; several days
+// always use their default inputs.
+func benchDays(puzzles []*registry.Puzzle, multi bool, args []string) error {
+	if benchRuns < 1 {
+		return errors.New("--bench requires a positive number of runs")
+	}
+	if multi && (len(inputs) > 0 || len(args) > 0) {
+		return errors.New(
+			"-i and puzzle arguments cannot be used when benchmarking several days")
+	}
+	if len(inputs) > 1 {
+		return errors.New("only one input can be benchmarked at a time")
+	}
+
+	var write func(io.Writer, []benchStats) error
+	switch benchFormat {
+	case "text":
+		write = writeBenchText
+	case "csv":
+		write = writeBenchCSV
+	case "json":
+		write = writeBenchJSON
+	default:
+		return fmt.Errorf("unknown benchmark format '%s'", benchFormat)
+	}
+
+	all := make([]benchStats, 0, 4*len(puzzles))
+	for _, puzzle := range puzzles {
+		if !puzzle.Implemented() {
+			continue
+		}
+		path := puzzle.Input
+		if len(inputs) > 0 {
+			path = inputs[0]
+		}
+		stats, err := benchDay(puzzle, path, args, benchRuns)
+		if err != nil {
+			return err
+		}
+		all = append(all, stats...)
+	}
+	return write(os.Stdout, all)
+}
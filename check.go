@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/fritzr/advent2020/registry"
+)
+
+// Run the selected days against their default inputs and either compare
+// their answers to the expected answers, or record them as the new baseline.
+func checkDays(puzzles []*registry.Puzzle, args []string) error {
+	if len(inputs) > 0 {
+		return errors.New("-i cannot be used with --check or --record")
+	}
+	if len(args) > 0 {
+		return errors.New(
+			"puzzle arguments cannot be used with --check or --record")
+	}
+
+	answers, err := registry.ReadAnswersFromFile(answersPath)
+	if err != nil {
+		return err
+	}
+
+	failures := 0
+	for _, d := range runDays(puzzles) {
+		if d.run == nil {
+			continue // unimplemented
+		}
+		if d.err != nil {
+			fmt.Printf("day %2d: ERROR %v\n", d.puzzle.Day, d.err)
+			fmt.Print(errorSnippet(&d))
+			failures++
+			continue
+		}
+		if record {
+			answers.Record(d.run)
+			continue
+		}
+		for _, check := range answers.Check(d.run) {
+			fmt.Printf("day %2d: %v\n", d.puzzle.Day, check)
+			if check.Verdict == registry.Fail {
+				failures++
+			}
+		}
+	}
+
+	if record {
+		if err = answers.WriteFile(answersPath); err != nil {
+			return err
+		}
+		fmt.Printf("recorded %d answers to %s\n", len(answers), answersPath)
+	}
+	if failures > 0 {
+		return fmt.Errorf("%d check(s) failed", failures)
+	}
+	return nil
+}
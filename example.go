@@ -0,0 +1,58 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/fritzr/advent2020/logging"
+	"github.com/fritzr/advent2020/registry"
+)
+
+// Run a day on its n-th example, subject to --timeout, logging to a
+// sub-logger of log.
+//
+// Puzzle arguments and --part override those of the example. Answers which
+// differ from the ones given for the example are reported as an error.
+func runExample(puzzle *registry.Puzzle, n int, args []string,
+	log *logging.Logger) dayRun {
+	run, err := puzzle.NewExampleRun(n)
+	if err != nil {
+		run = newRun(puzzle, fmt.Sprintf("example %d", n), nil, args, log)
+		return dayRun{puzzle, run, err, 0}
+	}
+	example, _ := puzzle.Example(n)
+	run.Log = log.Sub(puzzle.Name())
+	if len(args) > 0 {
+		run.Args = args
+	}
+	if part != 0 {
+		if example.Part != 0 && example.Part != part {
+			err = fmt.Errorf("day %d example %d only applies to part %d",
+				puzzle.Day, n, example.Part)
+			return dayRun{puzzle, run, err, 0}
+		}
+		run.Part = part
+	}
+
+	ctx, cancel := dayContext()
+	err = run.Solve(ctx)
+	cancel()
+	if err == nil {
+		err = exampleError(example.Check(run))
+	}
+	return dayRun{puzzle, run, err, run.Elapsed}
+}
+
+// Summarize the checks which failed, if any.
+func exampleError(checks []registry.Check) error {
+	failed := make([]string, 0, len(checks))
+	for _, check := range checks {
+		if check.Verdict != registry.Pass {
+			failed = append(failed, check.String())
+		}
+	}
+	if len(failed) == 0 {
+		return nil
+	}
+	return fmt.Errorf("wrong answer to example: %s", strings.Join(failed, "; "))
+}
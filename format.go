@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// A single record of machine-readable output: one part of one day.
+//
+// Every part the puzzle solves gets a record, even if it was not answered;
+// the error explains why. Answers to custom queries have part 0. If the day
+// failed after answering every part, an extra part 0 record holds the error.
+type resultRecord struct {
+	Day      int    `json:"day"`
+	Title    string `json:"title"`
+	Input    string `json:"input"`
+	Part     int    `json:"part"`
+	Label    string `json:"label"`
+	Answer   string `json:"answer"`
+	Duration int64  `json:"duration_ns"`
+	Error    string `json:"error"`
+}
+
+var recordFields = []string{
+	"day", "title", "input", "part", "label", "answer", "duration_ns", "error"}
+
+func resultRecords(d *dayRun) []resultRecord {
+	if d.run == nil {
+		return nil // not executed
+	}
+	errStr := ""
+	if d.err != nil {
+		errStr = d.err.Error()
+	}
+	records := make([]resultRecord, 0, d.puzzle.Parts+1)
+	answered := make(map[int]bool, d.puzzle.Parts)
+	for _, result := range d.run.Results {
+		records = append(records, resultRecord{
+			Day:      d.puzzle.Day,
+			Title:    d.puzzle.Title,
+			Input:    d.run.Input,
+			Part:     result.Part,
+			Label:    result.Label,
+			Answer:   result.Answer(),
+			Duration: int64(result.Elapsed),
+		})
+		answered[result.Part] = true
+	}
+	// Custom queries made with puzzle arguments need not answer every part.
+	reported := false
+	for part := 1; part <= d.puzzle.Parts; part++ {
+		if !answered[part] && d.run.Want(part) && len(d.run.Args) == 0 {
+			record := resultRecord{Day: d.puzzle.Day, Title: d.puzzle.Title,
+				Input: d.run.Input, Part: part, Error: errStr}
+			if d.err == nil {
+				record.Error = "no answer"
+			}
+			records = append(records, record)
+			reported = true
+		}
+	}
+	if d.err != nil && !reported {
+		records = append(records, resultRecord{Day: d.puzzle.Day,
+			Title: d.puzzle.Title, Input: d.run.Input,
+			Duration: int64(d.elapsed), Error: errStr})
+	}
+	return records
+}
+
+// Replace characters which would break a TSV row.
+func tsvField(field string) string {
+	return strings.NewReplacer("\t", " ", "\n", " ", "\r", " ").Replace(field)
+}
+
+func writeTSV(w io.Writer, records []resultRecord) error {
+	if _, err := fmt.Fprintln(w, strings.Join(recordFields, "\t")); err != nil {
+		return err
+	}
+	for _, r := range records {
+		_, err := fmt.Fprintln(w, strings.Join([]string{
+			strconv.Itoa(r.Day), tsvField(r.Title), tsvField(r.Input),
+			strconv.Itoa(r.Part),
+			tsvField(r.Label), tsvField(r.Answer),
+			strconv.FormatInt(r.Duration, 10), tsvField(r.Error)}, "\t"))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, records []resultRecord) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// Check the --format option.
+func validFormat(format string) error {
+	switch format {
+	case "text", "json", "tsv":
+		return nil
+	}
+	return fmt.Errorf("unknown output format '%s'", format)
+}
+
+// Write the results of several runs in a machine-readable format.
+//
+// Returns the number of days which failed.
+func writeResults(w io.Writer, format string, runs []dayRun) (int, error) {
+	records := make([]resultRecord, 0, 2*len(runs))
+	failures := 0
+	for index := range runs {
+		records = append(records, resultRecords(&runs[index])...)
+		if runs[index].err != nil {
+			failures++
+		}
+	}
+	var err error
+	switch format {
+	case "json":
+		err = writeJSON(w, records)
+	case "tsv":
+		err = writeTSV(w, records)
+	default:
+		err = validFormat(format)
+	}
+	return failures, err
+}
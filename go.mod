@@ -1,8 +1,5 @@
 module github.com/fritzr/advent2020
 
-go 1.13
+go 1.18
 
-require (
-	github.com/go-delve/delve v1.5.1 // indirect
-	gonum.org/v1/gonum v0.8.2
-)
+require github.com/go-delve/delve v1.5.1 // indirect
@@ -0,0 +1,210 @@
+// Package logging provides leveled loggers for puzzles and their parts.
+//
+// Each logger belongs to a subsystem with a dotted name, like "p08" for a day
+// or "p08.Simulator" for part of it. Levels are configured by name: a logger
+// uses the level of the longest configured prefix of its name, so tracing can
+// be enabled for a single day or for a single subsystem of a day.
+package logging
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// Level of detail of a message, or of the messages a logger writes.
+type Level int
+
+const (
+	// Off disables logging.
+	Off Level = iota
+	// Info is for summaries of what a puzzle has done.
+	Info
+	// Debug is for intermediate results, enabled with -v.
+	Debug
+	// Trace is for step-by-step detail, which may be very long.
+	Trace
+)
+
+var levelNames = []string{"off", "info", "debug", "trace"}
+
+func (l Level) String() string {
+	if l >= 0 && int(l) < len(levelNames) {
+		return levelNames[l]
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// ParseLevel parses a level name like "debug".
+func ParseLevel(name string) (Level, error) {
+	for level, levelName := range levelNames {
+		if name == levelName {
+			return Level(level), nil
+		}
+	}
+	return Off, fmt.Errorf("unknown log level '%s' (want one of %s)",
+		name, strings.Join(levelNames, ", "))
+}
+
+// Levels maps subsystem names to levels. The empty name sets the level for
+// everything else.
+type Levels map[string]Level
+
+// ParseLevels parses a comma-separated list of levels like
+// "debug,p08.Simulator=trace". A level without a name applies to everything.
+func ParseLevels(spec string) (Levels, error) {
+	levels := make(Levels)
+	for _, item := range strings.Split(spec, ",") {
+		if item == "" {
+			continue
+		}
+		name, levelName := "", item
+		if eq := strings.IndexByte(item, '='); eq >= 0 {
+			name, levelName = item[:eq], item[eq+1:]
+			if name == "" {
+				return nil, fmt.Errorf("missing name in '%s'", item)
+			}
+		}
+		level, err := ParseLevel(levelName)
+		if err != nil {
+			return nil, err
+		}
+		levels[name] = level
+	}
+	return levels, nil
+}
+
+// Set parses a spec like ParseLevels and adds it to the levels.
+func (ls Levels) Set(spec string) error {
+	levels, err := ParseLevels(spec)
+	if err != nil {
+		return err
+	}
+	for name, level := range levels {
+		ls[name] = level
+	}
+	return nil
+}
+
+func (ls Levels) String() string {
+	items := make([]string, 0, len(ls))
+	for name, level := range ls {
+		if name == "" {
+			items = append(items, level.String())
+		} else {
+			items = append(items, name+"="+level.String())
+		}
+	}
+	return strings.Join(items, ",")
+}
+
+// Level for the named subsystem: that of the longest configured prefix.
+func (ls Levels) Level(name string) Level {
+	for {
+		if level, ok := ls[name]; ok {
+			return level
+		}
+		if name == "" {
+			return Off
+		}
+		dot := strings.LastIndexByte(name, '.')
+		if dot < 0 {
+			dot = 0
+		}
+		name = name[:dot]
+	}
+}
+
+// Logger writes messages for one subsystem.
+//
+// A nil *Logger is valid and discards everything, so solvers may be used
+// without one. Loggers are safe for concurrent use; each message is written
+// with a single call to the output.
+type Logger struct {
+	name   string
+	level  Level
+	levels Levels
+	out    *output
+}
+
+type output struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// New returns the root logger, which writes to w at the given levels.
+func New(w io.Writer, levels Levels) *Logger {
+	return &Logger{level: levels.Level(""), levels: levels,
+		out: &output{w: w}}
+}
+
+// Sub returns the logger for a subsystem of this one. For example,
+// Sub("Simulator") of the logger "p08" is "p08.Simulator".
+func (l *Logger) Sub(name string) *Logger {
+	if l == nil {
+		return nil
+	}
+	if l.name != "" {
+		name = l.name + "." + name
+	}
+	return &Logger{name: name, level: l.levels.Level(name), levels: l.levels,
+		out: l.out}
+}
+
+// WithOutput returns a copy of the logger which writes to w instead, such as
+// a buffer to keep the messages of concurrent runs apart.
+func (l *Logger) WithOutput(w io.Writer) *Logger {
+	if l == nil {
+		return nil
+	}
+	return &Logger{name: l.name, level: l.level, levels: l.levels,
+		out: &output{w: w}}
+}
+
+// Name of the logger's subsystem.
+func (l *Logger) Name() string {
+	if l == nil {
+		return ""
+	}
+	return l.name
+}
+
+// Enabled reports whether messages at the level are written. Check it before
+// doing expensive work only needed for a message.
+func (l *Logger) Enabled(level Level) bool {
+	return l != nil && level != Off && level <= l.level
+}
+
+// Logf writes a message at the given level. A trailing newline is added if
+// needed.
+func (l *Logger) Logf(level Level, format string, args ...interface{}) {
+	if !l.Enabled(level) {
+		return
+	}
+	msg := fmt.Sprintf(format, args...)
+	if l.name != "" {
+		msg = l.name + ": " + msg
+	}
+	if !strings.HasSuffix(msg, "\n") {
+		msg += "\n"
+	}
+	l.out.mu.Lock()
+	defer l.out.mu.Unlock()
+	io.WriteString(l.out.w, msg)
+}
+
+// Infof writes a message at Info level.
+func (l *Logger) Infof(format string, args ...interface{}) {
+	l.Logf(Info, format, args...)
+}
+
+// Debugf writes a message at Debug level.
+func (l *Logger) Debugf(format string, args ...interface{}) {
+	l.Logf(Debug, format, args...)
+}
+
+// Tracef writes a message at Trace level.
+func (l *Logger) Tracef(format string, args ...interface{}) {
+	l.Logf(Trace, format, args...)
+}
@@ -0,0 +1,62 @@
+package logging
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseLevels(t *testing.T) {
+	levels, err := ParseLevels("debug,p08=info,p08.Simulator=trace")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		want Level
+	}{
+		{"", Debug},
+		{"p01", Debug},
+		{"p08", Info},
+		{"p08.FixLoop", Info},
+		{"p08.Simulator", Trace},
+		{"p08.Simulator.Step", Trace},
+		{"p08Simulator", Debug},
+	}
+	for _, test := range tests {
+		if got := levels.Level(test.name); got != test.want {
+			t.Errorf("Level(%q) = %v, want %v", test.name, got, test.want)
+		}
+	}
+
+	for _, spec := range []string{"loud", "p08=", "=trace"} {
+		if _, err := ParseLevels(spec); err == nil {
+			t.Errorf("ParseLevels(%q) succeeded, want an error", spec)
+		}
+	}
+}
+
+func TestLogger(t *testing.T) {
+	var out strings.Builder
+	log := New(&out, Levels{"": Info, "p08.Simulator": Trace}).Sub("p08")
+	log.Infof("loaded %d instructions", 3)
+	log.Debugf("hidden")
+	sim := log.Sub("Simulator")
+	sim.Tracef("STEP %d\n", 0)
+	if log.Enabled(Trace) || !sim.Enabled(Trace) {
+		t.Errorf("Enabled(Trace) = %v for p08 and %v for p08.Simulator",
+			log.Enabled(Trace), sim.Enabled(Trace))
+	}
+
+	want := "p08: loaded 3 instructions\np08.Simulator: STEP 0\n"
+	if out.String() != want {
+		t.Errorf("got output %q, want %q", out.String(), want)
+	}
+}
+
+func TestNilLogger(t *testing.T) {
+	var log *Logger
+	log.Sub("Simulator").Tracef("discarded")
+	if log.Enabled(Info) {
+		t.Error("nil logger is enabled")
+	}
+}
@@ -1,133 +1,366 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
 	"log"
 	"os"
 	"path"
-	"strconv"
+	"runtime"
+	"strings"
 	"time"
-	// "github.com/fritzr/advent2020/common"
-	"github.com/fritzr/advent2020/p01"
-	"github.com/fritzr/advent2020/p02"
-	"github.com/fritzr/advent2020/p03"
-	"github.com/fritzr/advent2020/p04"
-	"github.com/fritzr/advent2020/p05"
-	"github.com/fritzr/advent2020/p06"
-	"github.com/fritzr/advent2020/p07"
-	"github.com/fritzr/advent2020/p08"
-	"github.com/fritzr/advent2020/p09"
-	"github.com/fritzr/advent2020/p10"
-	"github.com/fritzr/advent2020/p11"
-	"github.com/fritzr/advent2020/p12"
-	"github.com/fritzr/advent2020/p13"
-	"github.com/fritzr/advent2020/p14"
-	"github.com/fritzr/advent2020/p15"
-	"github.com/fritzr/advent2020/p16"
-	"github.com/fritzr/advent2020/p17"
-	"github.com/fritzr/advent2020/p18"
-	"github.com/fritzr/advent2020/p19"
-	"github.com/fritzr/advent2020/p20"
+
+	"github.com/fritzr/advent2020/logging"
+	"github.com/fritzr/advent2020/registry"
 )
 
-type AdventMain func(path string, verbose bool, args []string) error
-
-var puzzles = [...]AdventMain{
-	p01.Main,
-	p02.Main,
-	p03.Main,
-	p04.Main,
-	p05.Main,
-	p06.Main,
-	p07.Main,
-	p08.Main,
-	p09.Main,
-	p10.Main,
-	p11.Main,
-	p12.Main,
-	p13.Main,
-	p14.Main,
-	p15.Main,
-	p16.Main,
-	p17.Main,
-	p18.Main,
-	p19.Main,
-	p20.Main,
+// Input paths given with -i, which may be repeated.
+type inputList []string
+
+func (l *inputList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *inputList) Set(path string) error {
+	if path == "-" {
+		for _, other := range *l {
+			if other == "-" {
+				return errors.New("standard input can only be read once")
+			}
+		}
+	}
+	*l = append(*l, path)
+	return nil
 }
 
 var verbose bool
-var input string
+var logLevels = make(logging.Levels)
+var inputs inputList
 var clock bool
+var check bool
+var record bool
+var answersPath string
+var benchRuns int
+var benchFormat string
+var format string
+var timeout time.Duration
+var part int
+var example int
+var jobs int
+var snippet bool
+
+// The root logger, writing to stderr at the levels from -v and --log.
+var logger *logging.Logger
 
 const (
-	verboseUsage = "enable debug messages"
-	inputUsage   = "puzzle input path"
-	timeUsage    = "output runtimes (ns precision)"
+	verboseUsage  = "enable debug messages (like --log debug)"
+	logUsage      = "set log `levels`, like debug,p08.Simulator=trace; may be repeated"
+	inputUsage    = "puzzle input `path` ('-' for stdin); may be repeated"
+	timeUsage     = "output runtimes (ns precision)"
+	checkUsage    = "compare answers against the expected answers file"
+	recordUsage   = "record answers as the new expected answers"
+	answersUsage  = "expected answers file"
+	benchUsage    = "benchmark: run each selected day `N` times and report statistics"
+	benchFmtUsage = "benchmark output format: text, csv or json"
+	formatUsage   = "output format: text, json or tsv"
+	timeoutUsage  = "abort each day after `duration` (e.g. 10s)"
+	cpuProfUsage  = "write a CPU profile to `file`"
+	memProfUsage  = "write a memory profile to `file`"
+	traceUsage    = "write an execution trace to `file`"
+	partUsage     = "solve only part `N` of each day"
+	exampleUsage  = "run example `N` from the puzzle description instead of the input"
+	jobsUsage     = "run up to `N` days in parallel (0 for one per CPU)"
+	snippetUsage  = "show the line of the input with a parse error"
 )
 
 func init() {
 	flag.BoolVar(&verbose, "verbose", false, verboseUsage)
 	flag.BoolVar(&verbose, "v", false, verboseUsage)
-	flag.StringVar(&input, "input", "", inputUsage)
-	flag.StringVar(&input, "i", "", inputUsage)
+	flag.Var(logLevels, "log", logUsage)
+	flag.Var(&inputs, "input", inputUsage)
+	flag.Var(&inputs, "i", inputUsage)
 	flag.BoolVar(&clock, "time", false, timeUsage)
 	flag.BoolVar(&clock, "t", false, timeUsage)
+	flag.BoolVar(&check, "check", false, checkUsage)
+	flag.BoolVar(&record, "record", false, recordUsage)
+	flag.StringVar(&answersPath, "answers", "answers.txt", answersUsage)
+	flag.IntVar(&benchRuns, "bench", 0, benchUsage)
+	flag.StringVar(&benchFormat, "bench-format", "text", benchFmtUsage)
+	flag.StringVar(&format, "format", "text", formatUsage)
+	flag.DurationVar(&timeout, "timeout", 0, timeoutUsage)
+	flag.StringVar(&cpuProfile, "cpuprofile", "", cpuProfUsage)
+	flag.StringVar(&memProfile, "memprofile", "", memProfUsage)
+	flag.StringVar(&tracePath, "trace", "", traceUsage)
+	flag.IntVar(&part, "part", 0, partUsage)
+	flag.IntVar(&example, "example", 0, exampleUsage)
+	flag.IntVar(&jobs, "jobs", 1, jobsUsage)
+	flag.IntVar(&jobs, "j", 1, jobsUsage)
+	flag.BoolVar(&snippet, "snippet", false, snippetUsage)
 }
 
 func Usage() {
 	fmt.Fprintf(flag.CommandLine.Output(),
-		`usage: %s [OPTIONS...] [--] [[day] [ARGS...]]
+		`usage: %[1]s [OPTIONS...] [--] [[day] [ARGS...]]
+       %[1]s [OPTIONS...] all|FIRST-LAST
+       %[1]s list
+       %[1]s serve [--addr ADDRESS] [--timeout DURATION] [--max-body BYTES]
+       %[1]s new DAY [TITLE...]
+       %[1]s [OPTIONS...] repl DAY [ARGS...]
 
 Run the given day's puzzle (defaults to the latest implemented puzzle).
-Additional puzzle-specific arguments may be accepted for some puzzles.
-Add -h or --help after the day to find out.
-All puzzles accept '-v' to run verbose and '-i PATH' to override the input.
+Some puzzles accept options of their own, listed below, after the day.
+Add -h or --help after the day to describe them.
+All puzzles accept '-v' to log debug messages and '-i PATH' to override the
+input.
+Use '--part N' to solve only one part of the puzzle.
+
+With --example N, run the N-th example from the puzzle description (see
+'list') instead of the input, and check the answers given for it. With 'all'
+or a range, days without that example are skipped.
+Use '-i -' to read the input from stdin. With several '-i' options, the day
+is run once for each input.
+
+With 'all' or a range of days like '1-10', run each day against its default
+input and print a summary of the answers and runtimes. The exit status is
+non-zero if any day fails.
+The 'list' command shows every registered puzzle.
+
+The 'new' command creates the package for a new day with a skeleton solver,
+a test file, an empty example and an empty input, and adds it to days.go so
+the runner includes it. Run it from within the module.
+
+The 'repl' command reads and parses a day's input (from -i PATH, --example N
+or the default input) once, then reads commands which explore it from stdin,
+like 'contains shiny gold' for day 7. Type 'help' for the day's commands.
+An interrupt cancels the current command; --timeout limits each one.
+
+The 'serve' command runs an HTTP service (on localhost:8080 by default) until
+interrupted. GET /days lists the puzzles as JSON. POST /days/N solves day N
+on the request body and responds with the records of --format=json. Use the
+query parameter 'part=N' to solve one part, and 'name=value' to pass the
+puzzle option -name. Each request is limited to --timeout (30s) and
+--max-body bytes of input (1 MiB).
+
+With --format=json or --format=tsv, print one record for every part of each
+executed day with the fields: day, title, input, part, label, answer,
+duration_ns and error.
+
+With --jobs N, run up to N of the selected days at once, for 'all', a range
+or --check. Each day's log is kept together and printed in order of day.
+
+With --check, compare each selected day's answers (all days by default) to
+the expected answers file and report PASS, FAIL or MISSING for every part.
+With --record, save the answers to that file as the new baseline instead.
+
+With --bench N, run each selected day N times and report the min, median,
+95th percentile and max time spent parsing and solving each part, and the
+allocations per run.
+
+With --timeout, each run of a day is cancelled once the duration has passed,
+as is the current day on interrupt. Days with long-running loops then stop
+and report how far they got; answers to parts already solved are kept.
+
+Input errors are reported with their line and column, like
+'p04/input:12:5: invalid field: "hgt183cm"'. With --snippet, the line is also
+shown, with the offending text marked.
+
+Puzzles log to stderr at levels info, debug and trace (or off). With -v,
+every puzzle logs at debug level. With --log, set the level of everything
+or of a single day or subsystem, like '--log p08=debug,p08.Simulator=trace'.
+The longest matching name wins.
+
+With --cpuprofile, --memprofile or --trace, profile the selected days and
+write the results for 'go tool pprof' or 'go tool trace'. The memory profile
+is written once everything has run.
 
 OPTIONS are:
 `, path.Base(os.Args[0]))
 	flag.PrintDefaults()
+
+	fmt.Fprintf(flag.CommandLine.Output(), "\nPuzzle-specific ARGS are:\n")
+	for _, p := range registry.All() {
+		if len(p.Options) > 0 {
+			fmt.Fprintf(flag.CommandLine.Output(), "  day %d:\n", p.Day)
+			p.WriteOptions(flag.CommandLine.Output(), "    ")
+		}
+	}
+}
+
+// List every registered puzzle with its status.
+func listPuzzles() {
+	for _, p := range registry.All() {
+		status := p.Status.String()
+		if p.Implemented() {
+			status = fmt.Sprintf("%d part(s)", p.Parts)
+		}
+		examples := ""
+		if len(p.Examples) > 0 {
+			examples = fmt.Sprintf("%d example(s)", len(p.Examples))
+		}
+		line := fmt.Sprintf("%2d  %-24s %-14s %-12s %s", p.Day, p.Title,
+			status, examples, p.Synopsis())
+		fmt.Println(strings.TrimRight(line, " "))
+	}
 }
 
 func main() {
 	flag.Usage = Usage
 	flag.Parse()
+	if err := validFormat(format); err != nil {
+		log.Fatal(err)
+	}
+	if part < 0 {
+		log.Fatalf("invalid part %d", part)
+	}
+	if example < 0 {
+		log.Fatalf("invalid example %d", example)
+	}
+	if example > 0 && (len(inputs) > 0 || check || record || benchRuns != 0) {
+		log.Fatal("--example cannot be used with -i, --check, --record or --bench")
+	}
+	if jobs < 0 {
+		log.Fatalf("invalid number of jobs %d", jobs)
+	} else if jobs == 0 {
+		jobs = runtime.NumCPU()
+	}
+	if jobs > 1 && benchRuns != 0 {
+		log.Fatal("--jobs cannot be used with --bench")
+	}
+	if _, ok := logLevels[""]; verbose && !ok {
+		logLevels[""] = logging.Debug
+	}
+	logger = logging.New(os.Stderr, logLevels)
+	handleInterrupt()
+	if err := startProfiling(); err != nil {
+		log.Fatal(err)
+	}
+	defer stopProfiling()
 
 	// day
-	day := len(puzzles)
-	puzzle := puzzles[len(puzzles)-1]
+	puzzle := registry.Latest()
 	args := flag.Args()
-	var err error
 	if flag.NArg() > 0 {
-		day, err = strconv.Atoi(flag.Arg(0))
-		if err != nil {
-			log.Fatal(err)
+		if flag.Arg(0) == "list" {
+			listPuzzles()
+			return
+		}
+		if flag.Arg(0) == "new" {
+			if err := newDayCommand(flag.Args()[1:]); err != nil {
+				fatal(err)
+			}
+			return
+		}
+		if flag.Arg(0) == "repl" {
+			if err := replCommand(flag.Args()[1:]); err != nil {
+				fatal(err)
+			}
+			return
+		}
+		if flag.Arg(0) == "serve" {
+			if err := serve(flag.Args()[1:]); err != nil {
+				fatal(err)
+			}
+			return
 		}
 
-		if day <= len(puzzles) {
-			puzzle = puzzles[day-1]
-		} else {
-			log.Fatal(fmt.Sprintf("unimplemented day '%d'\n", day))
+		puzzles, multi, err := parseDays(flag.Arg(0))
+		if err != nil {
+			fatal(err)
 		}
 		args = args[1:]
+		if benchRuns != 0 {
+			if err = benchDays(puzzles, multi, args); err != nil {
+				fatal(err)
+			}
+			return
+		}
+		if check || record {
+			if err = checkDays(puzzles, args); err != nil {
+				fatal(err)
+			}
+			return
+		}
+		if multi {
+			if err = runAll(puzzles, args); err != nil {
+				fatal(err)
+			}
+			return
+		}
+		puzzle = puzzles[0]
+	} else if check || record {
+		if err := checkDays(registry.All(), args); err != nil {
+			fatal(err)
+		}
+		return
+	}
+	if puzzle == nil {
+		fatal("no puzzles are implemented")
+	}
+	if puzzle.Implemented() && part > puzzle.Parts {
+		fatalf("day %d has no part %d", puzzle.Day, part)
+	}
+	if benchRuns != 0 {
+		err := benchDays([]*registry.Puzzle{puzzle}, false, args)
+		if err != nil {
+			fatal(err)
+		}
+		return
+	}
+	if !puzzle.Implemented() {
+		fatalf("day %d (%s) is %s", puzzle.Day, puzzle.Title, puzzle.Status)
 	}
 
-	// input override (-i path)
-	if input == "" {
-		input = path.Join(".", fmt.Sprintf("p%02d", day), "input")
+	// input override (-i path...)
+	paths := []string(inputs)
+	if len(paths) == 0 {
+		paths = []string{puzzle.Input}
 	}
 
-	// Run the selected puzzle. Pass additional arguments.
-	var stime time.Time
-	if clock {
-		stime = time.Now()
+	// Run the selected puzzle on each input. Pass additional arguments.
+	runs := make([]dayRun, 0, len(paths))
+	if example > 0 {
+		runs = append(runs, runExample(puzzle, example, args, logger))
+	} else {
+		for _, path := range paths {
+			runs = append(runs, runDay(puzzle, path, args, logger))
+		}
 	}
-	err = puzzle(input, verbose, args)
-	if err != nil {
-		log.Fatal(err)
+	if errors.Is(runs[0].err, registry.ErrHelp) {
+		command := fmt.Sprintf("%s [OPTIONS...] %d", path.Base(os.Args[0]),
+			puzzle.Day)
+		puzzle.WriteUsage(os.Stdout, command)
+		return
+	}
+	if format != "text" {
+		failures, err := writeResults(os.Stdout, format, runs)
+		if err != nil {
+			fatal(err)
+		}
+		if failures > 0 {
+			exit(1)
+		}
+		return
+	}
+
+	failures := 0
+	for _, d := range runs {
+		if len(runs) > 1 {
+			fmt.Printf("==> %s <==\n", d.run.Input)
+		}
+		for _, result := range d.run.Results {
+			fmt.Println(result)
+		}
+		if d.err != nil {
+			log.Print(d.err)
+			fmt.Fprint(os.Stderr, errorSnippet(&d))
+			failures++
+		}
+		if clock {
+			fmt.Printf("# elapsed time: %v\n", d.elapsed)
+		}
 	}
-	if clock {
-		elapsed := time.Since(stime)
-		fmt.Printf("# elapsed time: %v\n", elapsed)
+	if failures > 0 {
+		exit(1)
 	}
 }
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	gofmt "go/format"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+var templates = template.Must(template.ParseFS(templateFS, "templates/*.tmpl"))
+
+// The file listing the day packages for the runner to import.
+const daysFile = "days.go"
+
+var dayPackage = regexp.MustCompile(`^p[0-9][0-9]$`)
+
+// Parameters for the templates of a new day.
+type newDay struct {
+	Module  string
+	Package string
+	Day     int
+	Title   string
+}
+
+// Find the root of the module, and its path, from the working directory.
+func findModule() (root string, module string, err error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", "", err
+	}
+	for {
+		data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+		if err == nil {
+			scanner := bufio.NewScanner(bytes.NewReader(data))
+			for scanner.Scan() {
+				fields := strings.Fields(scanner.Text())
+				if len(fields) == 2 && fields[0] == "module" {
+					return dir, fields[1], nil
+				}
+			}
+			return "", "", errors.New("no module path in go.mod")
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", "", errors.New("go.mod not found")
+		}
+		dir = parent
+	}
+}
+
+// Render a template as formatted Go source for the file at path.
+func renderGoFile(path string, name string, data interface{}) ([]byte, error) {
+	var source bytes.Buffer
+	if err := templates.ExecuteTemplate(&source, name, data); err != nil {
+		return nil, err
+	}
+	formatted, err := gofmt.Source(source.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v", path, err)
+	}
+	return formatted, nil
+}
+
+// Render a template as Go source, and write it to a new file.
+func writeGoFile(path string, name string, data interface{}) error {
+	formatted, err := renderGoFile(path, name, data)
+	if err != nil {
+		return err
+	}
+	return writeNewFile(path, formatted)
+}
+
+// Write a file which must not exist yet.
+func writeNewFile(path string, data []byte) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err = file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Regenerate days.go to import every day package in the module root.
+func writeDaysFile(root string, module string) error {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	packages := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		if entry.IsDir() && dayPackage.MatchString(entry.Name()) {
+			packages = append(packages, entry.Name())
+		}
+	}
+	sort.Strings(packages)
+
+	path := filepath.Join(root, daysFile)
+	formatted, err := renderGoFile(path, "days.go.tmpl", struct {
+		Module   string
+		Packages []string
+	}{module, packages})
+	if err != nil {
+		return err
+	}
+
+	// Replace the file only once the new one is complete, so the tree still
+	// builds if anything fails.
+	file, err := os.CreateTemp(root, "."+daysFile+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name())
+	if _, err = file.Write(formatted); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Chmod(0644); err != nil {
+		file.Close()
+		return err
+	}
+	if err = file.Close(); err != nil {
+		return err
+	}
+	return os.Rename(file.Name(), path)
+}
+
+// Create the package for a new day from the templates, and add it to the
+// days imported by the runner.
+//
+// The package gets a skeleton solver, registered as unimplemented with a
+// placeholder example; a test file; an empty example and an empty input.
+// If any step fails, the new package is removed again.
+func newDayCommand(args []string) (err error) {
+	if len(args) == 0 {
+		return errors.New("usage: new DAY [TITLE...]")
+	}
+	day, err := strconv.Atoi(args[0])
+	if err != nil || day < 1 || day > 25 {
+		return fmt.Errorf("invalid day '%s'", args[0])
+	}
+	title := strings.Join(args[1:], " ")
+	if title == "" {
+		title = fmt.Sprintf("Day %d", day)
+	}
+
+	root, module, err := findModule()
+	if err != nil {
+		return err
+	}
+	params := newDay{module, fmt.Sprintf("p%02d", day), day, title}
+	dir := filepath.Join(root, params.Package)
+	if err = os.Mkdir(dir, 0755); err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	goFile := filepath.Join(dir, params.Package+".go")
+	if err = writeGoFile(goFile, "day.go.tmpl", params); err != nil {
+		return err
+	}
+	testFile := filepath.Join(dir, params.Package+"_test.go")
+	if err = writeGoFile(testFile, "day_test.go.tmpl", params); err != nil {
+		return err
+	}
+	for _, name := range []string{"example1.txt", "input"} {
+		if err = writeNewFile(filepath.Join(dir, name), nil); err != nil {
+			return err
+		}
+	}
+	if err = writeDaysFile(root, module); err != nil {
+		return err
+	}
+
+	fmt.Printf("created %s\n", dir)
+	fmt.Printf("next: paste the puzzle input into %s/input and the example "+
+		"into %s/example1.txt\n", params.Package, params.Package)
+	return nil
+}
@@ -1,11 +1,14 @@
 package p01
 
 import (
+  "context"
+  _ "embed"
   "strings"
   "errors"
-  "log"
   "fmt"
   "strconv"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
@@ -63,31 +66,22 @@ func NNumbersSummingTo(depth int, input []int, sum int) ([]int, error) {
   return []int{}, errors.New(fmt.Sprintf("no %d numbers sum to %d", depth, sum))
 }
 
-func LogNumbers(input []int) {
-  log.Print("p01: got ", len(input), " values")
-  i := 0
-  for ; i < (len(input) - 8); i++ {
-    log.Printf("%5d %5d %5d %5d %5d %5d %5d %5d\n",
-      input[i], input[i+1], input[i+2], input[i+3],
-      input[i+4], input[i+5], input[i+6], input[i+7])
-  }
-  for ; i < len(input); i++ {
-    log.Printf("%5d ", input[i])
-  }
-  if len(input) > 0 {
-    log.Printf("\n")
+func LogNumbers(log *logging.Logger, input []int) {
+  if !log.Enabled(logging.Debug) {
+    return
+  }
+  log.Debugf("got %d values", len(input))
+  var line strings.Builder
+  for i, value := range input {
+    fmt.Fprintf(&line, "%5d ", value)
+    if i % 8 == 7 || i == len(input) - 1 {
+      log.Debugf("%s", line.String())
+      line.Reset()
+    }
   }
 }
 
-func Usage() {
-  fmt.Println("usage: go run advent2020 1 [N [SUM=2020]]")
-  fmt.Println()
-  fmt.Println("Find N numbers which sum to SUM in the input.")
-  fmt.Println("If no args are given, print the results required by the puzzle.")
-  fmt.Println("If N is given, the default SUM is 2020 (from the puzzle).")
-}
-
-func do_sum(input []int, N int, sum int) error {
+func do_sum(r *registry.Run, part int, input []int, N int, sum int) error {
   var err error
   var result []int
   result, err = NNumbersSummingTo(N, input, sum)
@@ -99,79 +93,81 @@ func do_sum(input []int, N int, sum int) error {
     N, len(result)))
   }
 
-  // String representation of the numbers (1, 2, 3, ...)
+  // String representation of the numbers (1 + 2 + 3 ...)
   var nrep strings.Builder
   for idx, value := range result {
     nrep.WriteString(strconv.Itoa(value))
     if idx != len(result) - 1 {
-      nrep.WriteString(", ")
+      nrep.WriteString(" + ")
     }
   }
 
-  fmt.Printf("%d numbers which sum to %d: %s\n  Product: %d\n",
-    N, sum, nrep.String(), util.Product(result))
+  r.Answer(part,
+    fmt.Sprintf("Product of %d numbers which sum to %d", N, sum),
+    util.Product(result),
+    fmt.Sprintf("%s = %d", nrep.String(), sum))
 
   return nil
 }
 
 
-func Day1(input []int) error {
+func Day1(r *registry.Run, input []int, sum int) error {
   var err error
 
   // Part 1
-  if err = do_sum(input, 2, 2020); err != nil {
-    return err
+  if r.Want(1) {
+    if err = do_sum(r, 1, input, 2, sum); err != nil {
+      return err
+    }
   }
 
   // Part 2
-  if err = do_sum(input, 3, 2020); err != nil {
-    return err
+  if r.Want(2) {
+    if err = do_sum(r, 2, input, 3, sum); err != nil {
+      return err
+    }
   }
 
   return nil
 }
 
-func Main(input_path string, verbose bool, args []string) (error) {
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 1,
+    Title: "Report Repair",
+    Parts: 2,
+    Help: "Find N numbers in the input which sum to SUM, and print their product.\n" +
+      "Without -n, find 2 and then 3 numbers as the puzzle asks.",
+    Options: []registry.Option{
+      {Name: "n", Default: 0, Usage: "find `N` numbers",
+        Check: registry.Positive},
+      {Name: "sum", Default: 2020, Usage: "the `SUM` to look for"},
+    },
+    Positional: []string{"n", "sum"},
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"514579", "241861950"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) (error) {
   // Read the input.
   var input []int
   var err error
-  input, err = util.ReadNumbersFromFile(input_path)
-  if verbose {
-    LogNumbers(input)
-  }
+  input, err = util.ReadNumbers(r.Open())
+  LogNumbers(r.Log, input)
   if err != nil {
     return err
   }
+  r.Parsed()
 
-  // Check args. On empty, just do what the puzzle asked for.
-  if len(args) == 0 {
-    return Day1(input)
+  // Without -n, just do what the puzzle asked for.
+  if r.Int("n") == 0 {
+    return Day1(r, input, r.Int("sum"))
   }
-
-  // Otherwise, grab N and then look for the SUM.
-
-  if args[0] == "-h" || args[0] == "--help" {
-    Usage()
-    return nil
-  }
-
-  // N
-  var N int
-  N, err = strconv.Atoi(args[0])
-  if err != nil {
-    return err
-  }
-  args = args[1:]
-
-  // SUM
-  sum := 2020
-  if len(args) > 0 {
-    sum, err = strconv.Atoi(args[0])
-    if err != nil {
-      return err
-    }
-    args = args[1:]
-  }
-
-  return do_sum(input, N, sum)
+  return do_sum(r, 0, input, r.Int("n"), r.Int("sum"))
 }
@@ -1,6 +1,8 @@
 package p02
 
 import (
+  "context"
+  _ "embed"
   "io"
   "fmt"
   "bufio"
@@ -8,6 +10,7 @@ import (
   "strings"
   "strconv"
   "errors"
+  "github.com/fritzr/advent2020/registry"
 )
 
 type Password struct {
@@ -32,11 +35,16 @@ func (p *Password) P1Valid() bool {
   return count >= p.x
 }
 
+// Whether the policy character is at the given 1-based position.
+// Positions outside the password never match.
+func (p *Password) hasCharAt(position int) bool {
+  return position >= 1 && position <= len(p.password) &&
+    p.password[position - 1] == p.char
+}
+
 // Part 2 validation
 func (p *Password) P2Valid() bool {
-  p1 := p.password[p.x - 1] == p.char
-  p2 := p.password[p.y - 1] == p.char
-  return p1 != p2
+  return p.hasCharAt(p.x) != p.hasCharAt(p.y)
 }
 
 func parse_spec(spec string) (int, int, error) {
@@ -62,8 +70,7 @@ func ParsePasswords(r io.Reader) ([]Password, error) {
   scanner := bufio.NewScanner(r)
   scanner.Split(bufio.ScanWords)
 
-  passwords := make([]Password, 1000)
-  count := 0
+  passwords := make([]Password, 0, 1000)
   for scanner.Err() == nil && scanner.Scan() {
     // X-Y char: password (three words)
     spec := scanner.Text()
@@ -71,6 +78,8 @@ func ParsePasswords(r io.Reader) ([]Password, error) {
     var char string
     if scanner.Scan() && scanner.Err() == nil {
       char = scanner.Text()
+    } else if scanner.Err() == nil {
+      return passwords, fmt.Errorf("incomplete entry '%s'", spec)
     } else {
       break
     }
@@ -78,6 +87,8 @@ func ParsePasswords(r io.Reader) ([]Password, error) {
     var password string
     if scanner.Scan() && scanner.Err() == nil {
       password = scanner.Text()
+    } else if scanner.Err() == nil {
+      return passwords, fmt.Errorf("incomplete entry '%s'", spec)
     } else {
       break
     }
@@ -86,9 +97,11 @@ func ParsePasswords(r io.Reader) ([]Password, error) {
     if err != nil {
       return passwords, err
     }
+    if len(char) != 2 || char[1] != ':' {
+      return passwords, fmt.Errorf("invalid 'C:' character '%s'", char)
+    }
 
-    passwords[count] = Password{x, y, char[0], password}
-    count++
+    passwords = append(passwords, Password{x, y, char[0], password})
   }
 
   return passwords, scanner.Err()
@@ -105,33 +118,48 @@ func ParsePasswordsFromFile(path string) ([]Password, error) {
 
 type password_validator func(*Password) (bool)
 
-func report_valid(passwords []Password, validate password_validator) int {
-  lpass := len(passwords)
+func count_valid(passwords []Password, validate password_validator) int {
   nvalid := 0
   for _, password := range passwords {
     if validate(&password) {
       nvalid += 1
     }
   }
-
-  if nvalid < lpass {
-    fmt.Printf("Oh no! Only %d / %d passwords are valid!\n", nvalid, lpass)
-  } else {
-    fmt.Printf("Yay! All %d passwords are valid!\n", lpass)
-  }
   return nvalid
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  passwords, err := ParsePasswordsFromFile(input_path)
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 2,
+    Title: "Password Philosophy",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"2", "1"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  passwords, err := ParsePasswords(r.Open())
   if err != nil {
     return err
   }
+  r.Parsed()
 
-  fmt.Printf("Validation for part 1:\n  ")
-  _ = report_valid(passwords, (*Password).P1Valid)
-  fmt.Printf("Validation for part 2:\n  ")
-  _ = report_valid(passwords, (*Password).P2Valid)
+  if r.Want(1) {
+    r.Answer(1, "Valid passwords by count policy",
+      count_valid(passwords, (*Password).P1Valid),
+      fmt.Sprintf("out of %d passwords", len(passwords)))
+  }
+  if r.Want(2) {
+    r.Answer(2, "Valid passwords by position policy",
+      count_valid(passwords, (*Password).P2Valid),
+      fmt.Sprintf("out of %d passwords", len(passwords)))
+  }
 
   return nil
 }
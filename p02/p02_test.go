@@ -0,0 +1,47 @@
+package p02
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 2) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 2) }
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"1-3 a abcde\n",
+	"x-3 a: abcde\n",
+	"1-3: abcde\n",
+	"1 a: abcde\n",
+}
+
+func TestParsePasswordsMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := ParsePasswords(strings.NewReader(input)); err == nil {
+			t.Errorf("ParsePasswords(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzParsePasswords(f *testing.F) {
+	for _, input := range malformed {
+		f.Add([]byte(input))
+	}
+	f.Add(registrytest.InputData(f, 2))
+	f.Add([]byte("1-3 a: abcde\n"))
+	f.Fuzz(func(t *testing.T, data []byte) {
+		passwords, err := ParsePasswords(bytes.NewReader(data))
+		if err != nil {
+			return
+		}
+		for index := range passwords {
+			passwords[index].P1Valid()
+			passwords[index].P2Valid()
+		}
+	})
+}
@@ -1,119 +1,86 @@
 package p03
 
 import (
+  "context"
+  _ "embed"
   "fmt"
-  "io"
-  "os"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
-func get_line_width(r *os.File) (int, error) {
-  nread := 1
-  pos := 0
-  var err error
-  char := make([]byte, 1)
-  for char[0] != '\n' && nread > 0 && err == nil {
-    nread, err = r.Read(char)
-    pos += nread
-  }
-  return pos - 1, err
-}
-
-func TobogganSled(rows *os.File, slope int, speed int) (int, int, error) {
+// Count the trees and open squares hit going down the forest (which repeats
+// to the right) by slope columns for every speed rows.
+func TobogganSled(forest *util.Grid[byte], slope int, speed int) (int, int) {
   const tree_char = '#'
-  const open_char = '.'
-
-
-  // Skip the first line, since we always start in the top-left (an open space)
-  line_width, err := get_line_width(rows)
-  if err != nil {
-    return 0, 0, err
-  }
 
   ntrees := 0
   nopen := 0
-  line_pos := 0
-  nread := 1
-  char := make([]byte, 1)
-
-  _, err = rows.Seek(1, io.SeekStart)
-  if err != nil {
-    return 0, 0, err
-  }
-  for nread > 0 && err == nil {
-    // need to seek to end of line, then to the next line position
-    line_remaining := line_width - line_pos
-    line_pos = (line_pos + slope) % line_width
-
-    // right N, down 1 (line width)
-    _, err = rows.Seek(
-      int64(line_remaining + line_pos + (speed - 1) * (line_width + 1)),
-      io.SeekCurrent)
-    if err != nil {
-      return 0, 0, err
-    }
-
-    nread, err = rows.Read(char)
-    if nread == len(char) && err == nil {
-      if char[0] == tree_char {
-        ntrees++
-      } else {
-        nopen++
-      }
+  step := util.Point{Row: speed, Col: slope}
+  // Skip the first line, since we always start in the top-left (an open space)
+  for pos := step; pos.Row < forest.Rows(); pos = pos.Add(step) {
+    if forest.Get(pos) == tree_char {
+      ntrees++
+    } else {
+      nopen++
     }
   }
+  return ntrees, nopen
+}
 
-  if err == io.EOF {
-    err = nil
-  }
-
-  return ntrees, nopen, err
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 3,
+    Title: "Toboggan Trajectory",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"7", "336"}},
+    },
+    Main: Main,
+  })
 }
 
-func do_sled(file *os.File, slope int, speed int) (int, int, error) {
-  var (ntrees int; nopen int; err error)
-  _, err = file.Seek(0, io.SeekStart)
+func Main(ctx context.Context, r *registry.Run) error {
+  lines, err := util.ReadLines(r.Open())
   if err != nil {
-    return 0, 0, err
+    return err
   }
-
-  ntrees, nopen, err = TobogganSled(file, slope, speed)
-  if err != nil {
-    return ntrees, nopen, err
-  }
-
-  fmt.Printf("Slope %d x %d: I dodged %d trees and hit %d.\n",
-    slope, speed, nopen, ntrees)
-  return ntrees, nopen, err
-}
-
-func Main(input_path string, verbose bool, args []string) error {
-  file, err := os.Open(input_path)
+  forest, err := util.ParseGrid(lines, util.ByteSet(".#"))
   if err != nil {
     return err
   }
-  defer file.Close()
+  forest.Wrap = util.WrapCols
+  r.Parsed()
 
-  var ntrees int
+  var ntrees, nopen int
   trees := make([]int, 5)
+  details := make([]string, 0, 5)
   treenum := 0
-  slow_slopes := []int{1, 3, 5, 7}
-  for _, slope := range slow_slopes {
-    ntrees, _, err = do_sled(file, slope, 1)
-    if err != nil {
-      return err
+  slopes := [][2]int{{1, 1}, {3, 1}, {5, 1}, {7, 1}, {1, 2}}
+  for _, slope := range slopes {
+    // Part 1 only needs the 3 x 1 slope.
+    if !r.Want(2) && slope != [2]int{3, 1} {
+      continue
     }
+    ntrees, nopen = TobogganSled(forest, slope[0], slope[1])
+    details = append(details, fmt.Sprintf(
+      "Slope %d x %d: I dodged %d trees and hit %d.",
+      slope[0], slope[1], nopen, ntrees))
     trees[treenum] = ntrees
     treenum++
-  }
 
-  ntrees, _, err = do_sled(file, 1, 2)
-  if err != nil {
-    return err
+    // Part 1: only the 3 x 1 slope.
+    if slope == [2]int{3, 1} {
+      r.Answer(1, "Trees hit on slope 3 x 1", ntrees)
+    }
   }
-  trees[treenum] = ntrees
 
-  fmt.Printf("Product: %d\n", util.Product(trees))
+  if r.Want(2) {
+    r.Answer(2, "Product of trees hit on all slopes", util.Product(trees),
+      details...)
+  }
 
   return nil
 }
@@ -1,12 +1,14 @@
 package p04
 
 import (
+  "context"
+  _ "embed"
   "io"
-  "bufio"
   "errors"
   "strings"
   "fmt"
   "strconv"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
@@ -96,20 +98,20 @@ func (p *Passport) Valid() bool {
 
 func split_word(word string) (string, string, error) {
   fields := strings.Split(word, ":")
-  if len(fields) != 2 {
-    return "", "", errors.New(fmt.Sprintf("invalid field '%s'", word))
+  if len(fields) != 2 || fields[0] == "" {
+    return "", "", errors.New("invalid field")
   }
   return fields[0], fields[1], nil
 }
 
+// Read the fields of a passport. An invalid field is reported as a
+// util.ParseError relative to the start of the data.
 func (p *Passport) Read(data string) error {
   // Split all the words in the data.
-  words := bufio.NewScanner(strings.NewReader(data))
-  words.Split(bufio.ScanWords)
-  for words.Scan() {
-    key, value, err := split_word(words.Text())
+  for _, word := range util.FieldsAt(data) {
+    key, value, err := split_word(word.Text)
     if err != nil {
-      return err
+      return util.ErrorAt(data, word.Offset, word.Text, err)
     }
     p.fields[key] = value
   }
@@ -123,31 +125,48 @@ func NewPassport(data string) (Passport, error) {
 }
 
 func ReadPassports(input io.Reader) ([]Passport, error) {
-  scanner := bufio.NewScanner(input)
-  scanner.Split(util.ScanLineGroups)
-
-  passports := make([]Passport, 0, 1056)
-  for scanner.Scan() {
-    p, err := NewPassport(scanner.Text())
+  groups := util.NewLineGroupScanner(input)
+  passports := make([]Passport, 0)
+  for groups.Scan() {
+    p, err := NewPassport(groups.Text())
     if err != nil {
-      return passports, err
+      return passports, util.AtLine(err, groups.Line())
     }
     passports = append(passports, p)
   }
 
-  return passports, scanner.Err()
+  return passports, groups.Err()
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  iPassports, err := util.ReadFile(input_path,
-    func(input io.Reader) (interface{}, error) {
-      return ReadPassports(input)
-    })
+//go:embed example1.txt
+var example1 string
+
+//go:embed example2.txt
+var example2 string
+
+//go:embed example3.txt
+var example3 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 4,
+    Title: "Passport Processing",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"2", "2"}},
+      {Input: example2, Answers: []string{"4", "0"}},
+      {Input: example3, Answers: []string{"4", "4"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  passports, err := ReadPassports(r.Open())
   if err != nil {
     return err
   }
-
-  passports := iPassports.([]Passport)
+  r.Parsed()
   npresent := 0
   nvalid := 0
   for _, p := range passports {
@@ -159,8 +178,10 @@ func Main(input_path string, verbose bool, args []string) error {
     }
   }
 
-  fmt.Printf("%d / %d passports have all fields\n", npresent, len(passports))
-  fmt.Printf("%d / %d passports are valid\n", nvalid, len(passports))
+  r.Answer(1, "Passports with all fields present", npresent,
+    fmt.Sprintf("out of %d passports", len(passports)))
+  r.Answer(2, "Passports with all fields valid", nvalid,
+    fmt.Sprintf("out of %d passports", len(passports)))
 
   return nil
 }
@@ -0,0 +1,43 @@
+package p04
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 4) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 4) }
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"byr",
+	"byr:1990 iyr",
+	"byr:1990\n:2012",
+}
+
+func TestNewPassportMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := NewPassport(input); err == nil {
+			t.Errorf("NewPassport(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzNewPassport(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	for _, line := range registrytest.InputLines(f, 4) {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		passport, err := NewPassport(data)
+		if err != nil {
+			return
+		}
+		passport.Present()
+		passport.Valid()
+	})
+}
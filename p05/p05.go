@@ -1,12 +1,14 @@
 package p05
 
 import (
+  "context"
+  _ "embed"
   "io"
   "bufio"
   "fmt"
-  "log"
   "os"
   "errors"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
@@ -98,22 +100,39 @@ func find_missing_seat(passes []BoardingPass, max_id int) int {
   return -1
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  passes, err := ReadBoardingPassesFromFile(input_path)
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 5,
+    Title: "Binary Boarding",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Part: 1, Answers: []string{"820"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  passes, err := ReadBoardingPasses(r.Open())
   if err != nil {
     return err
   }
+  if len(passes) == 0 {
+    return errors.New("no boarding passes")
+  }
+  r.Parsed()
 
-  max_id := 0
+  max_id := -1
   var max_seat *Seat
   var max_pass *BoardingPass
   for _, pass := range passes {
     seat := pass.Decode(128, 8)
     id := seat.ID()
-    if verbose {
-      log.Print(fmt.Sprintf("%s => (%d, %d) [ID=%d]\n",
-        pass.steps, seat.row, seat.column, id))
-    }
+    r.Log.Tracef("%s => (%d, %d) [ID=%d]",
+      pass.steps, seat.row, seat.column, id)
     if id > max_id {
       max_id = id
       max_seat = &seat
@@ -121,15 +140,17 @@ func Main(input_path string, verbose bool, args []string) error {
     }
   }
 
-  fmt.Printf("Read %d boarding passes.\n", len(passes))
-
   // Part 1
-  fmt.Printf("Highest seat from: %s => (%d, %d) [ID=%d]\n",
-    max_pass.steps, max_seat.row, max_seat.column, max_id)
+  r.Answer(1, "Highest seat ID", max_id,
+    fmt.Sprintf("Read %d boarding passes.", len(passes)),
+    fmt.Sprintf("Highest seat from: %s => (%d, %d)",
+      max_pass.steps, max_seat.row, max_seat.column))
 
   // Part 2
-  missing_id := find_missing_seat(passes, max_id)
-  fmt.Printf("Missing seat ID: %d\n", missing_id)
+  if r.Want(2) {
+    missing_id := find_missing_seat(passes, max_id)
+    r.Answer(2, "Missing seat ID", missing_id)
+  }
 
   return nil
 }
@@ -0,0 +1,55 @@
+package p05
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 5) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 5) }
+
+func TestSolveMalformed(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"FFFFFFFLLX\n",
+		"FBFBBFFRL\n",
+	} {
+		if err := registrytest.Solve(t, 5, input); err == nil {
+			t.Errorf("solved %q", input)
+		}
+	}
+}
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"",
+	"FBFBBFFRLX",
+	"FBFBBFFRL",
+	"RBFBBFFRLR",
+}
+
+func TestNewBoardingPassMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := NewBoardingPass(input); err == nil {
+			t.Errorf("NewBoardingPass(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzNewBoardingPass(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	for _, line := range registrytest.InputLines(f, 5) {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		pass, err := NewBoardingPass(line)
+		if err != nil {
+			return
+		}
+		pass.Decode(128, 8)
+	})
+}
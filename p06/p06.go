@@ -1,49 +1,51 @@
 package p06
 
 import (
+  "context"
+  _ "embed"
   "io"
-  "bufio"
   "fmt"
+  "strings"
   "unicode"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
 type ResponseGroup struct {
-  any map[byte]int
-  all map[byte]int
+  any util.Set[byte]
+  all util.Set[byte]
   members int
 }
 
 func NewResponseGroup(data string) ResponseGroup {
-  // If we got any responses, we got one more than the number of newlines,
-  // since ScanLineGroups never returns trailing newlines, so initialize
-  // members to 1.
-  group := ResponseGroup{make(map[byte]int, 26), make(map[byte]int, 26), 1}
-  for _, question := range data {
-    // Record the questions which anyone answered.
-    if !unicode.IsSpace(question) {
-      group.any[byte(question)]++
-    } else if question == '\n' {
-      group.members++
+  // Each line holds the responses of one member of the group. Groups never
+  // contain blank lines, so every line is a member.
+  group := ResponseGroup{util.NewSet[byte](), util.NewSet[byte](), 0}
+  for _, line := range strings.Split(data, "\n") {
+    responses := util.NewSet[byte]()
+    for _, question := range []byte(line) {
+      if !unicode.IsSpace(rune(question)) {
+        responses.Add(question)
+      }
+    }
+    // Record the questions which anyone answered, and which everyone did.
+    group.any = group.any.Union(responses)
+    if group.members == 0 {
+      group.all = responses
+    } else {
+      group.all = group.all.Intersection(responses)
     }
+    group.members++
   }
   // If we didn't actually get any responses, reset members to zero.
-  if len(group.any) == 0 {
+  if group.any.Len() == 0 {
     group.members = 0
-  } else {
-    // Record the questions which everyone answered.
-    for question, count := range group.any {
-      if count == group.members {
-        group.all[question] = 1
-      }
-    }
   }
   return group
 }
 
 func ReadResponseGroups(input io.Reader) ([]ResponseGroup, error) {
-  scanner := bufio.NewScanner(input)
-  scanner.Split(util.ScanLineGroups)
+  scanner := util.NewLineGroupScanner(input)
 
   groups := make([]ResponseGroup, 0, 1024)
   for scanner.Scan() {
@@ -52,29 +54,40 @@ func ReadResponseGroups(input io.Reader) ([]ResponseGroup, error) {
   return groups, scanner.Err()
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  iResponses, err := util.ReadFile(input_path,
-    func(input io.Reader) (interface{}, error) {
-      return ReadResponseGroups(input)
-    })
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 6,
+    Title: "Custom Customs",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"11", "6"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  responses, err := ReadResponseGroups(r.Open())
   if err != nil {
     return err
   }
-
-  responses := iResponses.([]ResponseGroup)
-  fmt.Printf("Read responses from %d groups.\n", len(responses))
+  r.Parsed()
 
   any_sum := 0
   all_sum := 0
   for _, group := range responses {
-    any_sum += len(group.any)
-    all_sum += len(group.all)
+    any_sum += group.any.Len()
+    all_sum += group.all.Len()
   }
 
   // Part 1
-  fmt.Printf("Sum of \"any\" response counts is: %d\n", any_sum)
+  r.Answer(1, "Sum of \"any\" response counts", any_sum,
+    fmt.Sprintf("Read responses from %d groups.", len(responses)))
   // Part 2
-  fmt.Printf("Sum of \"all\" response counts is: %d\n", all_sum)
+  r.Answer(2, "Sum of \"all\" response counts", all_sum)
 
   return nil
 }
@@ -0,0 +1,21 @@
+package p06
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 6) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 6) }
+
+func FuzzNewResponseGroup(f *testing.F) {
+	f.Add("abc\nab")
+	for _, line := range registrytest.InputLines(f, 6) {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		NewResponseGroup(data)
+	})
+}
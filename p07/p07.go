@@ -1,17 +1,20 @@
 package p07
 
 import (
+  "context"
+  _ "embed"
   "io"
   "bufio"
   "os"
   "fmt"
   "strings"
   "errors"
+  "sort"
   "strconv"
+  "github.com/fritzr/advent2020/registry"
+  "github.com/fritzr/advent2020/util"
 )
 
-var gVerbose bool
-
 // Double-edged graph.
 type Bag struct {
   name string
@@ -67,22 +70,33 @@ func (g *RuleGraph) AddRule(contained string, container string, N int) error {
 // Parse a rule of the form:
 // <color1> bags contain {<N> <color> bags[, ...]|no other bags}.
 func (g *RuleGraph) ParseRule(rule string) error {
-  parts := strings.SplitN(rule, " bags contain ", 2)
+  const separator = " bags contain "
+  parts := strings.SplitN(rule, separator, 2)
+  if len(parts) != 2 {
+    return util.NewParseError(0, 1, rule, errors.New("malformed rule"))
+  }
   containerColor := parts[0]
-  rules := strings.Split(parts[1], ", ")
-  if len(rules) == 0 || (len(rules) == 1 && strings.HasPrefix(rules[0], "no")) {
+  listStart := len(parts[0]) + len(separator)
+  rules := util.SplitAt(parts[1], ", ")
+  if len(rules) == 1 && strings.HasPrefix(rules[0].Text, "no") {
     g.AddBag(containerColor)
   } else {
     for _, rule := range rules {
-      ruleParts := strings.SplitN(rule, " ", 2)
+      column := listStart + rule.Offset + 1
+      ruleParts := strings.SplitN(rule.Text, " ", 2)
+      if len(ruleParts) != 2 {
+        return util.NewParseError(0, column, rule.Text,
+          errors.New("malformed bag list"))
+      }
       num, err := strconv.Atoi(ruleParts[0])
       if err != nil {
-        return err
+        return util.NewParseError(0, column, ruleParts[0], err)
       }
       contained := ruleParts[1]
       end := strings.Index(contained, " bag")
       if end < 0 {
-        return errors.New(fmt.Sprintf("malformed bag list '%s'", contained))
+        return util.NewParseError(0, column + len(ruleParts[0]) + 1, contained,
+          errors.New("malformed bag list"))
       }
       containedColor := contained[:end]
       if err = g.AddRule(containedColor, containerColor, num); err != nil {
@@ -166,16 +180,33 @@ func (g *RuleGraph) SortContains() []*Bag {
   return stack
 }
 
+// Count the bags which a bag must contain, directly or indirectly.
+//
+// Counts are remembered in the given map, which may be shared between calls.
+func (g *RuleGraph) CountContains(bagName string, counts map[string]int) int {
+  if count, ok := counts[bagName]; ok {
+    return count
+  }
+  count := 0
+  if bag := g.bags[bagName]; bag != nil {
+    for contained, num := range bag.contains {
+      count += num * (1 + g.CountContains(contained, counts))
+    }
+  }
+  counts[bagName] = count
+  return count
+}
+
 func ReadRules(input io.Reader) (*RuleGraph, error) {
   scanner := bufio.NewScanner(input)
   scanner.Split(bufio.ScanLines)
   graph := NewRuleGraph()
-  for scanner.Scan() {
+  for line := 1; scanner.Scan(); line++ {
     if err := graph.ParseRule(scanner.Text()); err != nil {
-      return graph, err
+      return graph, util.AtLine(err, line)
     }
   }
-  return graph, nil
+  return graph, scanner.Err()
 }
 
 func ReadRulesFromFile(path string) (*RuleGraph, error) {
@@ -187,32 +218,50 @@ func ReadRulesFromFile(path string) (*RuleGraph, error) {
   return ReadRules(file)
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
-  graph, err := ReadRulesFromFile(input_path)
+//go:embed example1.txt
+var example1 string
+
+//go:embed example2.txt
+var example2 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 7,
+    Title: "Handy Haversacks",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"4", "32"}},
+      {Input: example2, Part: 2, Answers: []string{"", "126"}},
+    },
+    Main: Main,
+    Repl: Repl,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  log := r.Log
+  graph, err := ReadRules(r.Open())
   if err != nil {
     return err
   }
+  r.Parsed()
 
   // Count the number of bags which may indirectly contain 'shiny gold' bags.
-  mayContainGold := make(map[string]int, len(graph.bags))
-  graph.TraverseContainedBy("shiny gold",
-    func(contained string, container string, num int) bool{
-      isNew := mayContainGold[container] == 0
-      mayContainGold[container] = num
-      if (verbose) {
-        fmt.Printf("%s bags contain %d %s bags\n", container, num, contained)
-      }
-      return isNew
-    })
+  if r.Want(1) {
+    mayContainGold := make(map[string]int, len(graph.bags))
+    graph.TraverseContainedBy("shiny gold",
+      func(contained string, container string, num int) bool{
+        isNew := mayContainGold[container] == 0
+        mayContainGold[container] = num
+        log.Tracef("%s bags contain %d %s bags", container, num, contained)
+        return isNew
+      })
 
-  if (verbose) {
-    fmt.Println("==============================================")
+    r.Answer(1, "Bags which may eventually contain shiny gold bags",
+      len(mayContainGold))
   }
-  fmt.Printf("Bags which may eventually contain shiny gold bags: %d.\n",
-    len(mayContainGold))
-  if (verbose) {
-    fmt.Println("==============================================")
+  if !r.Want(2) {
+    return nil
   }
 
   // Count the number of bags which every bag must contain.
@@ -221,25 +270,102 @@ func Main(input_path string, verbose bool, args []string) error {
   for _, bag := range containsDag {
     for containedName, num := range bag.contains {
       containsCount[bag.name] += num * (1 + containsCount[containedName])
-      if (verbose) {
-        fmt.Printf("%s bags contain %d %s bags, each counting for %d, now %d\n",
-          bag.name, num, containedName, containsCount[containedName],
-          containsCount[bag.name])
-      }
+      log.Tracef("%s bags contain %d %s bags, each counting for %d, now %d",
+        bag.name, num, containedName, containsCount[containedName],
+        containsCount[bag.name])
     }
-    if verbose && len(bag.contains) == 0 {
-      fmt.Printf("%s bags contain no other bags\n", bag.name)
+    if len(bag.contains) == 0 {
+      log.Tracef("%s bags contain no other bags", bag.name)
     }
   }
 
-  if (verbose) {
-    fmt.Println("==============================================")
-  }
-  fmt.Printf("Number of bags which shiny gold must contain: %d.\n",
+  r.Answer(2, "Number of bags which shiny gold must contain",
     containsCount["shiny gold"])
-  if (verbose) {
-    fmt.Println("==============================================")
-  }
 
   return nil
 }
+
+// Look up the bag named by the words of a command, like "shiny gold".
+func (g *RuleGraph) commandBag(args []string) (*Bag, error) {
+  if len(args) == 0 {
+    return nil, registry.ErrUsage
+  }
+  name := strings.Join(args, " ")
+  bag := g.bags[name]
+  if bag == nil {
+    return nil, fmt.Errorf("no rule mentions %s bags", name)
+  }
+  return bag, nil
+}
+
+// Print the rules of the given bags in order of name.
+func writeRules(w io.Writer, rules map[string]int) {
+  names := make([]string, 0, len(rules))
+  for name := range rules {
+    names = append(names, name)
+  }
+  sort.Strings(names)
+  for _, name := range names {
+    fmt.Fprintf(w, "  %d %s\n", rules[name], name)
+  }
+}
+
+func Repl(ctx context.Context, r *registry.Run) ([]registry.Command, error) {
+  graph, err := ReadRules(r.Open())
+  if err != nil {
+    return nil, err
+  }
+  return []registry.Command{
+    {Name: "rule", Args: "BAG", Usage: "show the bags which BAG directly " +
+      "contains, and which directly contain it",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        bag, err := graph.commandBag(args)
+        if err != nil {
+          return err
+        }
+        fmt.Fprintf(w, "%s bags contain:\n", bag.name)
+        writeRules(w, bag.contains)
+        fmt.Fprintf(w, "%s bags are contained by:\n", bag.name)
+        writeRules(w, bag.containedBy)
+        return nil
+      }},
+    {Name: "contains", Args: "BAG", Usage: "count the bags which BAG must " +
+      "eventually contain",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        bag, err := graph.commandBag(args)
+        if err != nil {
+          return err
+        }
+        counts := make(map[string]int, len(graph.bags))
+        fmt.Fprintf(w, "%s bags contain %d bags\n", bag.name,
+          graph.CountContains(bag.name, counts))
+        return nil
+      }},
+    {Name: "containedby", Args: "BAG", Usage: "list the bags which may " +
+      "eventually contain BAG",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        bag, err := graph.commandBag(args)
+        if err != nil {
+          return err
+        }
+        containers := make([]string, 0, len(graph.bags))
+        seen := make(map[string]bool, len(graph.bags))
+        graph.TraverseContainedBy(bag.name,
+          func(contained string, container string, num int) bool {
+            if seen[container] {
+              return false
+            }
+            seen[container] = true
+            containers = append(containers, container)
+            return true
+          })
+        sort.Strings(containers)
+        for _, container := range containers {
+          fmt.Fprintf(w, "  %s\n", container)
+        }
+        fmt.Fprintf(w, "%d bags may contain %s bags\n", len(containers),
+          bag.name)
+        return nil
+      }},
+  }, nil
+}
@@ -0,0 +1,39 @@
+package p07
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 7) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 7) }
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"light red bags contain",
+	"light red bags contain x bright white bags.",
+	"light red bags hold 1 bright white bag.",
+}
+
+func TestReadRulesMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := ReadRules(strings.NewReader(input)); err == nil {
+			t.Errorf("ReadRules(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzReadRules(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	for _, line := range registrytest.InputLines(f, 7) {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		ReadRules(strings.NewReader(data))
+	})
+}
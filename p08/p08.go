@@ -1,20 +1,23 @@
 package p08
 
 import (
+  "context"
+  _ "embed"
   "fmt"
   "io"
   "errors"
   "strings"
   "strconv"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
-var gVerbose = false
-
 type Simulator struct {
   insns []string
   accumulator int
   pc int
+  log *logging.Logger
 }
 
 func (s *Simulator) acc(ops []string) (int, error) {
@@ -22,25 +25,19 @@ func (s *Simulator) acc(ops []string) (int, error) {
   if err != nil {
     return s.pc, err
   }
-  if gVerbose {
-    fmt.Printf("  ACC %d\n", value)
-  }
+  s.log.Tracef("  ACC %d", value)
   s.accumulator += value
   return s.pc + 1, nil
 }
 
 func (s *Simulator) nop(ops []string) (int, error) {
-  if gVerbose {
-    fmt.Printf("  NOP\n")
-  }
+  s.log.Tracef("  NOP")
   return s.pc + 1, nil
 }
 
 func (s *Simulator) jmp(ops []string) (int, error) {
   value, err := strconv.Atoi(ops[0])
-  if gVerbose {
-    fmt.Printf("  JMP %d\n", value)
-  }
+  s.log.Tracef("  JMP %d", value)
   if err != nil {
     return s.pc, err
   }
@@ -57,9 +54,7 @@ var opTable = map[string]opFunc {
   "jmp": (*Simulator).jmp }
 
 func (s *Simulator) Jump(address int) error {
-  if gVerbose {
-    fmt.Printf("  PC %d => %d\n", s.pc, address)
-  }
+  s.log.Tracef("  PC %d => %d", s.pc, address)
   if address >= 0 && address < len(s.insns) {
     s.pc = address
   } else {
@@ -77,9 +72,7 @@ func (s *Simulator) Step() error {
   if s.pc >= len(s.insns) {
     return errors.New(fmt.Sprintf("overflow at offset %d", s.pc))
   }
-  if gVerbose {
-    fmt.Printf("  STEP %d: insn=%s\n", s.pc, s.insns[s.pc])
-  }
+  s.log.Tracef("  STEP %d: insn=%s", s.pc, s.insns[s.pc])
   return s.Exec(s.insns[s.pc])
 }
 
@@ -124,6 +117,9 @@ func (s *Simulator) Reset() {
 }
 
 func (s *Simulator) FindLoop() (int, int, error) {
+  if s.pc < 0 || s.pc >= len(s.insns) {
+    return -1, -1, fmt.Errorf("no instruction at %d", s.pc)
+  }
   return s.FindLoopFrom(s.pc, s.insns[s.pc])
 }
 
@@ -136,40 +132,61 @@ func (s *Simulator) FindLoopFrom(pc int, insn string) (int, int, error) {
     return -1, -1, err
   }
 
-  if gVerbose {
-    fmt.Printf("Exec First: [%02d] %s (acc=%d)\n",
-      s.pc, s.insns[s.pc], s.accumulator)
-  }
+  s.log.Tracef("Exec First: [%02d] %s (acc=%d)",
+    s.pc, s.insns[s.pc], s.accumulator)
 
-  err := s.Step()
-  for err == nil {
-    if gVerbose {
-      fmt.Printf("Exec Next: [%02d] %s (acc=%d)\n",
-        s.pc, s.insns[s.pc], s.accumulator)
-    }
-    // Loop!
+  for {
+    // Loop! Stop before executing any instruction a second time.
     if visited[s.pc] {
       return lastPc, s.pc, nil
     }
+    s.log.Tracef("Exec Next: [%02d] %s (acc=%d)",
+      s.pc, s.insns[s.pc], s.accumulator)
     lastPc = s.pc
     visited[s.pc] = true
-    err = s.Step()
-  }
-  if err != nil {
-    return -1, -1, err
+    if err := s.Step(); err != nil {
+      return -1, -1, err
+    }
   }
+}
 
-  return -1, -1, nil
+// Parse a program of one instruction per line, like "jmp -4". Returns the
+// instructions with their fields separated by a single space. An invalid
+// instruction is reported as a util.ParseError.
+func ParseProgram(lines []string) ([]string, error) {
+  if len(lines) == 0 {
+    return nil, errors.New("empty program")
+  }
+  insns := make([]string, len(lines))
+  for index, line := range lines {
+    fields := util.FieldsAt(line)
+    if len(fields) != 2 {
+      return nil, util.NewParseError(index + 1, 1, line,
+        errors.New("expected an instruction like 'acc +1'"))
+    }
+    if opTable[fields[0].Text] == nil {
+      return nil, util.NewParseError(index + 1, fields[0].Offset + 1,
+        fields[0].Text, errors.New("unrecognized instruction"))
+    }
+    if _, err := strconv.Atoi(fields[1].Text); err != nil {
+      return nil, util.NewParseError(index + 1, fields[1].Offset + 1,
+        fields[1].Text, err)
+    }
+    insns[index] = fields[0].Text + " " + fields[1].Text
+  }
+  return insns, nil
 }
 
-func NewSimulator(insns []string) *Simulator {
+// The simulator logs each step at trace level; log may be nil.
+func NewSimulator(insns []string, log *logging.Logger) *Simulator {
   s := new(Simulator)
   s.insns = insns
+  s.log = log
   return s
 }
 
-func Part1(insns []string) (int, error) {
-  sim := NewSimulator(insns)
+func Part1(r *registry.Run, insns []string) (int, error) {
+  sim := NewSimulator(insns, r.Log.Sub("Simulator"))
 
   loopFrom, loopTo, err := sim.FindLoop()
   if err != nil {
@@ -178,15 +195,24 @@ func Part1(insns []string) (int, error) {
   if loopFrom < 0 || loopTo < 0 {
     return sim.accumulator, errors.New("No loop found!")
   }
-  fmt.Printf("Loop: [%02d] %s => [%02d] %s\n",
-    loopFrom, sim.insns[loopFrom], loopTo, sim.insns[loopTo])
-
-  fmt.Printf("Accumulator: %d\n", sim.accumulator)
+  r.Answer(1, "Accumulator before looping", sim.accumulator,
+    fmt.Sprintf("Loop: [%02d] %s => [%02d] %s",
+      loopFrom, sim.insns[loopFrom], loopTo, sim.insns[loopTo]))
   return sim.accumulator, nil
 }
 
-func FixLoop(insns []string) (int, int, error) {
-  sim := NewSimulator(insns)
+// Find the instruction to swap between jmp and nop so the program terminates.
+//
+// Attempts are logged to log at debug level, and the simulation to its
+// "Simulator" subsystem.
+//
+// Returns the address of the instruction, the instruction which replaces it,
+// and the accumulator at termination. The instructions themselves are not
+// modified; the simulator works on a copy.
+func FixLoop(insns []string, log *logging.Logger) (int, string, int, error) {
+  patched := make([]string, len(insns))
+  copy(patched, insns)
+  sim := NewSimulator(patched, log.Sub("Simulator"))
   for pc, insn := range insns {
     if strings.HasPrefix(insn, "acc ") {
       continue
@@ -202,9 +228,7 @@ func FixLoop(insns []string) (int, int, error) {
 
     for _, insn := range []string{insn, altInsn} {
       sim.insns[pc] = insn
-      if gVerbose {
-        fmt.Printf("Trying [%d] %s (acc=%d)...\n", pc, insn)
-      }
+      log.Debugf("Trying [%d] %s (acc=%d)...", pc, insn, sim.accumulator)
       // TODO... we could probably do this smarter than running the
       // whole program each time.
       sim.Reset()
@@ -216,52 +240,218 @@ func FixLoop(insns []string) (int, int, error) {
         } else {
           pc = sim.pc // location of the error
         }
-        return pc, sim.accumulator, err
+        return pc, insn, sim.accumulator, err
       }
       // No loop... but this doesn't result in io.EOF.
       if from < 0 || to < 0 {
-        return pc, sim.accumulator, nil
-      }
-      if gVerbose {
-        fmt.Printf("... [%d] %s caused loop [%d] %s -> [%d] %s\n",
-          pc, insn, from, insns[from], to, insns[to])
+        return pc, insn, sim.accumulator, nil
       }
+      log.Debugf("... [%d] %s caused loop [%d] %s -> [%d] %s",
+        pc, insn, from, insns[from], to, insns[to])
     }
 
     // Restore the original instruction for the next attempt.
     sim.insns[pc] = insn
   }
 
-  return -1, 0, errors.New("No fixable loops found")
+  return -1, "", 0, errors.New("No fixable loops found")
 }
 
-func Part2(insns []string) (int, int, error) {
-  fixedPc, acc, err := FixLoop(insns)
+func Part2(r *registry.Run, insns []string) (int, int, error) {
+  fixedPc, fixedInsn, acc, err := FixLoop(insns, r.Log)
   if err != nil {
     return fixedPc, acc, err
   }
-  fmt.Printf("Fixed loop at [%d] %s! Accumulator: %d\n",
-    fixedPc, insns[fixedPc], acc)
+  r.Answer(2, "Accumulator after fixing the loop", acc,
+    fmt.Sprintf("Fixed loop at [%d] %s!", fixedPc, fixedInsn))
   return fixedPc, acc, nil
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 8,
+    Title: "Handheld Halting",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"5", "8"}},
+    },
+    Main: Main,
+    Repl: Repl,
+  })
+}
 
-  insns, err := util.ReadLinesFromFile(input_path)
+func Main(ctx context.Context, r *registry.Run) error {
+  lines, err := util.ReadLines(r.Open())
   if err != nil {
     return err
   }
+  insns, err := ParseProgram(lines)
+  if err != nil {
+    return err
+  }
+  r.Parsed()
 
-  fmt.Printf("Loaded %d instructions\n", len(insns))
+  r.Log.Debugf("Loaded %d instructions", len(insns))
 
-  if _, err = Part1(insns); err != nil {
-    return err
+  if r.Want(1) {
+    if _, err = Part1(r, insns); err != nil {
+      return err
+    }
   }
 
-  if _, _, err = Part2(insns); err != nil {
-    return err
+  if r.Want(2) {
+    if _, _, err = Part2(r, insns); err != nil {
+      return err
+    }
   }
 
   return nil
 }
+
+// Print the state of the simulator and its next instruction.
+func (s *Simulator) writeState(w io.Writer) {
+  fmt.Fprintf(w, "[%03d] %s (acc=%d)\n", s.pc, s.Insn(s.pc), s.accumulator)
+}
+
+// Parse the optional number argument of a command.
+func commandCount(args []string, count int) (int, error) {
+  if len(args) > 1 {
+    return 0, registry.ErrUsage
+  }
+  if len(args) == 1 {
+    var err error
+    if count, err = strconv.Atoi(args[0]); err != nil {
+      return 0, registry.ErrUsage
+    }
+  }
+  return count, nil
+}
+
+func Repl(ctx context.Context, r *registry.Run) ([]registry.Command, error) {
+  lines, err := util.ReadLines(r.Open())
+  if err != nil {
+    return nil, err
+  }
+  insns, err := ParseProgram(lines)
+  if err != nil {
+    return nil, err
+  }
+  sim := NewSimulator(insns, r.Log.Sub("Simulator"))
+  return []registry.Command{
+    {Name: "state", Usage: "show the PC, the next instruction and the " +
+      "accumulator",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        sim.writeState(w)
+        return nil
+      }},
+    {Name: "list", Args: "[ADDRESS [N]]", Usage: "list N (10) instructions " +
+      "from ADDRESS (the PC)",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        address, count := sim.pc, 10
+        var err error
+        if len(args) > 0 {
+          if address, err = commandCount(args[:1], address); err != nil {
+            return err
+          }
+          args = args[1:]
+        }
+        if count, err = commandCount(args, count); err != nil {
+          return err
+        }
+        if address < 0 {
+          return fmt.Errorf("invalid address %d", address)
+        }
+        for pc := address; pc < address + count && pc < len(sim.insns); pc++ {
+          marker := " "
+          if pc == sim.pc {
+            marker = ">"
+          }
+          fmt.Fprintf(w, "%s[%03d] %s\n", marker, pc, sim.insns[pc])
+        }
+        return nil
+      }},
+    {Name: "step", Args: "[N]", Usage: "execute N (1) instructions",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        count, err := commandCount(args, 1)
+        if err != nil {
+          return err
+        }
+        for ; count > 0; count-- {
+          if err = ctx.Err(); err != nil {
+            return err
+          }
+          if err = sim.Step(); err == io.EOF {
+            fmt.Fprintf(w, "terminated (acc=%d)\n", sim.accumulator)
+            return nil
+          } else if err != nil {
+            return err
+          }
+        }
+        sim.writeState(w)
+        return nil
+      }},
+    {Name: "exec", Args: "INSN", Usage: "execute INSN, like 'nop +0', in " +
+      "place of the next instruction",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        if len(args) == 0 {
+          return registry.ErrUsage
+        }
+        if err := sim.Exec(strings.Join(args, " ")); err == io.EOF {
+          fmt.Fprintf(w, "terminated (acc=%d)\n", sim.accumulator)
+          return nil
+        } else if err != nil {
+          return err
+        }
+        sim.writeState(w)
+        return nil
+      }},
+    {Name: "jump", Args: "ADDRESS", Usage: "set the PC",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        if len(args) != 1 {
+          return registry.ErrUsage
+        }
+        address, err := strconv.Atoi(args[0])
+        if err != nil {
+          return registry.ErrUsage
+        }
+        if err = sim.Jump(address); err != nil {
+          return err
+        }
+        sim.writeState(w)
+        return nil
+      }},
+    {Name: "reset", Usage: "set the PC and accumulator to 0",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        sim.Reset()
+        sim.writeState(w)
+        return nil
+      }},
+    {Name: "loop", Usage: "run until an instruction would run a second time",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        from, to, err := sim.FindLoop()
+        if err == io.EOF {
+          fmt.Fprintf(w, "terminated (acc=%d)\n", sim.accumulator)
+          return nil
+        } else if err != nil {
+          return err
+        }
+        fmt.Fprintf(w, "loop: [%03d] %s => [%03d] %s (acc=%d)\n",
+          from, sim.insns[from], to, sim.insns[to], sim.accumulator)
+        return nil
+      }},
+    {Name: "fix", Usage: "find the instruction to swap so the program " +
+      "terminates",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        pc, insn, acc, err := FixLoop(sim.insns, r.Log)
+        if err != nil {
+          return err
+        }
+        fmt.Fprintf(w, "[%03d] %s => %s terminates (acc=%d)\n",
+          pc, sim.insns[pc], insn, acc)
+        return nil
+      }},
+  }, nil
+}
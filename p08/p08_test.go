@@ -0,0 +1,59 @@
+package p08
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 8) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 8) }
+
+func TestSolveMalformed(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"nop\n",
+		"acc +1\nhalt +0\n",
+		"jmp +x\n",
+		"jmp -1\n",
+	} {
+		if err := registrytest.Solve(t, 8, input); err == nil {
+			t.Errorf("solved %q", input)
+		}
+	}
+}
+
+// Programs the parser must reject.
+var malformed = []string{
+	"",
+	"nop",
+	"acc +1 +2",
+	"halt +0",
+	"jmp x",
+}
+
+func TestParseProgramMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := ParseProgram(strings.Split(input, "\n")); err == nil {
+			t.Errorf("ParseProgram(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzParseProgram(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	f.Add(string(registrytest.InputData(f, 8)))
+	f.Add("nop +0\nacc +1\njmp -2")
+	f.Fuzz(func(t *testing.T, data string) {
+		insns, err := ParseProgram(strings.Split(data, "\n"))
+		if err != nil {
+			return
+		}
+		NewSimulator(insns, nil).FindLoop()
+		FixLoop(insns, nil)
+	})
+}
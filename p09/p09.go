@@ -1,35 +1,36 @@
 package p09
 
 import (
+  "context"
+  _ "embed"
   "fmt"
   "errors"
-  "strconv"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
   "github.com/fritzr/advent2020/p01"
 )
 
-var gVerbose = false
-
 var BadXMASValue = errors.New("bad XMAS value")
 
 type XMASValidator struct {
-  size int
-  count int
-  buffer *util.RingBuffer
+  buffer *util.RingBuffer[int]
+  log *logging.Logger
 }
 
-func NewXMASValidator(size int) *XMASValidator {
+// The validator logs each value at trace level; log may be nil.
+func NewXMASValidator(size int, log *logging.Logger) *XMASValidator {
   v := new(XMASValidator)
-  v.buffer = util.NewRingBuffer(size)
-  v.size = size
+  v.buffer = util.NewRingBuffer[int](size)
+  v.log = log
   return v
 }
 
 func (v *XMASValidator) Read(value int) error {
-  if v.count >= v.size {
+  if v.buffer.Full() {
     // Verify the value is a sum of two previous values.
     mruIndex := -1
-    size := v.size
+    size := v.buffer.Len()
     // Called like: for Next() { x := Get(); ... }
     v1, v2, err := p01.NumbersSummingToIter(value,
       func() bool { // Next()
@@ -37,25 +38,23 @@ func (v *XMASValidator) Read(value int) error {
         return mruIndex < size
       },
       func() int { // Get()
-        return v.buffer.GetLast(mruIndex).(int)
+        return v.buffer.GetLast(mruIndex)
       })
     if err != nil {
       return BadXMASValue
     }
-    if gVerbose {
-      fmt.Printf("%d: sum of %d and %d\n", value, v1, v2)
-    }
-  }
-  if gVerbose {
-    fmt.Printf("inserted %d\n", value)
+    v.log.Tracef("%d: sum of %d and %d", value, v1, v2)
   }
+  v.log.Tracef("inserted %d", value)
   v.buffer.Push(value)
-  v.count += 1 // number of elements added
   return nil
 }
 
 // Find a contiguous series of numbers with the desired sum.
-func FindSumWindow(data []int, sum int) (lo int, hi int, windowSum int) {
+//
+// Each step is logged to log at trace level; log may be nil.
+func FindSumWindow(data []int, sum int, log *logging.Logger) (
+    lo int, hi int, windowSum int) {
   if len(data) == 0 {
     return 0, 0, 0
   }
@@ -66,14 +65,12 @@ func FindSumWindow(data []int, sum int) (lo int, hi int, windowSum int) {
   // We may run into problems because the numbers do not increase monotonically.
   next := hi
   sgn := 1
-  fmt.Printf("  pushing [%d] %d\n", hi, data[hi])
+  log.Tracef("  pushing [%d] %d", hi, data[hi])
   for hi < len(data) && windowSum != sum {
     // Adjust the sum according to the last operation.
     windowSum += sgn * data[next]
-    if gVerbose {
-      fmt.Printf("Sum [%d:%d](%d,...,%d) = %d\n",
-        lo, hi, data[lo], data[hi], windowSum)
-    }
+    log.Tracef("Sum [%d:%d](%d,...,%d) = %d",
+      lo, hi, data[lo], data[hi], windowSum)
     if windowSum < sum {
       // Expand the window to increase the sum.
       sgn = 1
@@ -89,28 +86,22 @@ func FindSumWindow(data []int, sum int) (lo int, hi int, windowSum int) {
       hi++
       next = hi
       sgn = 1
-      if gVerbose {
-        if hi < len(data) {
-          fmt.Printf("  pushing [%d] %d\n", hi, data[hi])
-        } else {
-          fmt.Printf("  at end [%d]\n", hi)
-        }
+      if hi < len(data) {
+        log.Tracef("  pushing [%d] %d", hi, data[hi])
+      } else {
+        log.Tracef("  at end [%d]", hi)
       }
     } else if windowSum > sum {
       sgn = -1
       if lo < hi {
         // Subtract the oldest value and move up the left bound.
-        if gVerbose {
-          fmt.Printf("  popping [%d] %d\n", lo, data[lo])
-        }
+        log.Tracef("  popping [%d] %d", lo, data[lo])
         next = lo
         lo++
       } else {
         // Bum window... lo == hi and the value here is too large.
         // Try to move past it.
-        if gVerbose {
-          fmt.Printf("  skipping [%d] %d\n", lo, data[lo])
-        }
+        log.Tracef("  skipping [%d] %d", lo, data[lo])
         next = lo
         lo++
         hi++
@@ -127,37 +118,6 @@ func FindSumWindow(data []int, sum int) (lo int, hi int, windowSum int) {
   return lo, hi, windowSum
 }
 
-func Usage() {
-  fmt.Println("usage: advent2020 9 [main opts...] [-w window_size=25]\n")
-  fmt.Println("")
-  fmt.Println("The -w option allows you to customize the XMAS window size.\n")
-}
-
-func ParseArgs(args []string) (windowSize int, err error) {
-  if len(args) > 0 && (args[0] == "-h" || args[0] == "--help") {
-    Usage()
-    return windowSize, nil
-  }
-
-  if len(args) > 0 && (args[0] == "-w" || args[0] == "--window-size") {
-    if len(args) == 1 {
-      Usage()
-      return windowSize, errors.New("-w expects an argument")
-    }
-    windowSize, err = strconv.Atoi(args[1])
-    if err != nil {
-      return windowSize, err
-    }
-    if windowSize <= 0 {
-      return windowSize, errors.New("window size must be positive")
-    }
-  } else {
-    windowSize = 25
-  }
-
-  return windowSize, nil
-}
-
 func VerifySum(data []int, sum int) error {
   if len(data) == 0 {
     return errors.New("sum window is empty")
@@ -173,22 +133,36 @@ func VerifySum(data []int, sum int) error {
   return nil
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
-
-  windowSize, err := ParseArgs(args)
-  if err != nil {
-    return err
-  }
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 9,
+    Title: "Encoding Error",
+    Parts: 2,
+    Options: []registry.Option{
+      {Name: "w", Aliases: []string{"window-size"}, Default: 25,
+        Usage: "size of the XMAS preamble `WINDOW`",
+        Check: registry.Positive},
+    },
+    Examples: []registry.Example{
+      {Input: example1, Args: []string{"-w", "5"}, Answers: []string{"127", "62"}},
+    },
+    Main: Main,
+  })
+}
 
-  var data []int
-  data, err = util.ReadNumbersFromFile(input_path)
+func Main(ctx context.Context, r *registry.Run) error {
+  windowSize := r.Int("w")
+  data, err := util.ReadNumbers(r.Open())
   if err != nil {
     return err
   }
+  r.Parsed()
 
   // Validate the input, first of all.
-  validator := NewXMASValidator(windowSize)
+  validator := NewXMASValidator(windowSize, r.Log.Sub("XMASValidator"))
   var (idx int; value int)
   for idx, value = range data {
     err = validator.Read(value)
@@ -199,7 +173,7 @@ func Main(input_path string, verbose bool, args []string) error {
 
   // Part 1: expecting a bad value.
   if err == BadXMASValue {
-    fmt.Printf("First bad value was [%d] %d\n", idx, value)
+    r.Answer(1, "First bad value", value, fmt.Sprintf("at index [%d]", idx))
   } else if err == nil {
     return errors.New("all numbers were unexpectedly valid!")
   } else {
@@ -207,18 +181,23 @@ func Main(input_path string, verbose bool, args []string) error {
   }
 
   // Part 2: find a contiguous sequence of numbers which sum to the bad value.
-  loIndex, hiIndex, sum := FindSumWindow(data[:idx], value)
+  if !r.Want(2) {
+    return nil
+  }
+  loIndex, hiIndex, sum := FindSumWindow(data[:idx], value,
+    r.Log.Sub("FindSumWindow"))
   if sum == value {
     lo := data[loIndex]
     hi := data[hiIndex]
     window := data[loIndex:(hiIndex+1)]
-    fmt.Printf("Sum window: [%d:%d] (%d,...,%d)\n", loIndex, hiIndex, lo, hi)
     if err = VerifySum(window, sum); err != nil {
       return err
     }
     _, min := util.IMin(window)
     _, max := util.IMax(window)
-    fmt.Printf("  min=%d, max=%d, min + max=%d\n", min, max, min + max)
+    r.Answer(2, "Sum of the min and max of the sum window", min + max,
+      fmt.Sprintf("Sum window: [%d:%d] (%d,...,%d)", loIndex, hiIndex, lo, hi),
+      fmt.Sprintf("min=%d, max=%d", min, max))
   } else {
     return errors.New(fmt.Sprintf(
       "failed to find sum window! stopped at [%d:%d] (%d,...,%d)\n",
@@ -1,14 +1,15 @@
 package p10
 
 import (
+  "context"
+  _ "embed"
   "fmt"
   "sort"
   "errors"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
-var gVerbose = false
-
 func combFrom(idx int, adapters []int, memo map[int]int) (nComb int) {
   // Special case: combFrom(-1) uses the implicit starting adapter (0).
   var adapter int
@@ -42,11 +43,34 @@ func AdapterCombinations(adapters []int) int {
   return combFrom(-1, adapters, memo)
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  adapters, err := util.ReadNumbersFromFile(input_path)
+//go:embed example1.txt
+var example1 string
+
+//go:embed example2.txt
+var example2 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 10,
+    Title: "Adapter Array",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"35", "8"}},
+      {Input: example2, Answers: []string{"220", "19208"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  adapters, err := util.ReadNumbers(r.Open())
   if err != nil {
     return err
   }
+  if len(adapters) == 0 {
+    return errors.New("no adapters")
+  }
+  r.Parsed()
 
   sort.Ints(adapters)
   adapters = append(adapters, 3 + adapters[len(adapters)-1])
@@ -55,7 +79,7 @@ func Main(input_path string, verbose bool, args []string) error {
   last := 0
   for idx, joltage := range adapters {
     diff := joltage - last
-    if diff <= 3 {
+    if diff >= 0 && diff <= 3 {
       diffs[diff]++
     } else {
       return errors.New(fmt.Sprintf(
@@ -65,13 +89,18 @@ func Main(input_path string, verbose bool, args []string) error {
     last = joltage
   }
 
-  fmt.Printf("Diffs (%d):\n", len(adapters))
-  util.PrintArray(diffs)
-  fmt.Printf("  [1] %d * [3] %d = %d\n",
-    diffs[1], diffs[3], diffs[1] * diffs[3])
+  r.Log.Debugf("Diffs (%d):", len(adapters))
+  for idx, count := range diffs {
+    r.Log.Debugf("  [%2d] %d", idx, count)
+  }
+  r.Answer(1, "Product of 1-jolt and 3-jolt differences",
+    diffs[1] * diffs[3],
+    fmt.Sprintf("[1] %d * [3] %d", diffs[1], diffs[3]))
 
-  fmt.Printf("# of ways to arrange adapters: %d\n",
-    AdapterCombinations(adapters))
+  if r.Want(2) {
+    r.Answer(2, "# of ways to arrange adapters",
+      AdapterCombinations(adapters))
+  }
 
   return nil
 }
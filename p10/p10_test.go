@@ -0,0 +1,23 @@
+package p10
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 10) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 10) }
+
+func TestSolveMalformed(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"-1\n",
+		"1\n5\n",
+	} {
+		if err := registrytest.Solve(t, 10, input); err == nil {
+			t.Errorf("solved %q", input)
+		}
+	}
+}
@@ -1,71 +1,44 @@
 package p11
 
 import (
+  "context"
+  _ "embed"
   "fmt"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
 type SeatMap struct {
-  seats [][]byte
-  width int
+  seats *util.Grid[byte]
   nEmpty int
   nOccupied int
   nFloor int
 }
 
-const SEAT_ERROR = byte(' ')
 const SEAT_FLOOR = byte('.')
 const SEAT_EMPTY = byte('L')
 const SEAT_OCCUPIED = byte('#')
 
-func BCount(arr []byte, c byte) (n int) {
-  for _, a := range arr {
-    if a == c {
-      n++
-    }
+func NewSeatMap(lines []string) (*SeatMap, error) {
+  seats, err := util.ParseGrid(lines,
+    util.ByteSet(string([]byte{SEAT_FLOOR, SEAT_EMPTY, SEAT_OCCUPIED})))
+  if err != nil {
+    return nil, err
   }
-  return n
-}
-
-func NewSeatMap(lines []string) *SeatMap {
   m := new(SeatMap)
-  m.seats = make([][]byte, len(lines))
-  for row, line := range lines {
-    m.seats[row] = []byte(line)
-  }
-  if len(lines) > 0 {
-    m.width = len(lines[0])
-  } else {
-    m.width = 0
-  }
+  m.seats = seats
   // Count how many of stuff we have.
-  for _, row := range m.seats {
-    m.nEmpty += BCount(row, SEAT_EMPTY)
-    m.nOccupied += BCount(row, SEAT_OCCUPIED)
-  }
-  m.nFloor = len(m.seats) * m.width - (m.nEmpty + m.nOccupied)
-  return m
+  m.nEmpty = seats.Count(func(c byte) bool { return c == SEAT_EMPTY })
+  m.nOccupied = seats.Count(func(c byte) bool { return c == SEAT_OCCUPIED })
+  m.nFloor = len(seats.Cells()) - (m.nEmpty + m.nOccupied)
+  return m, nil
 }
 
-type Coordinate struct {
-  row int
-  col int
-}
-
-func (c *Coordinate) Add(row int, col int) {
-  c.row += row
-  c.col += col
-}
-
-func (c *Coordinate) Eq(o *Coordinate) bool {
-  return c.row == o.row && c.col == o.col
-}
-
-func (m *SeatMap) At(c *Coordinate) byte {
-  if c.row < 0 || c.row >= len(m.seats) || c.col < 0 || c.col >= m.width {
-    return SEAT_ERROR
-  }
-  return m.seats[c.row][c.col]
+// Copy the seat map, to simulate from the same start more than once.
+func (m *SeatMap) Clone() *SeatMap {
+  clone := *m
+  clone.seats = m.seats.Clone()
+  return &clone
 }
 
 // Simulate one step of people occupying seats.
@@ -73,32 +46,30 @@ func (m *SeatMap) At(c *Coordinate) byte {
 // Returns true if anything changed.
 // The stand() and sit() functions return whether an occupied seat becomes
 // empty, or an empty seat becomes occupied, respectively.
-func (m *SeatMap) fill(occupied func(*SeatMap, int, int) int,
+func (m *SeatMap) fill(occupied func(*SeatMap, util.Point) int,
                        stand func(*SeatMap, int) bool,
                        sit func(*SeatMap, int) bool) bool {
   // Apply rules simultaneously by storing changes before comitting them.
-  newOccupied := make([]Coordinate, 0, m.Empty())
-  newEmpty := make([]Coordinate, 0, m.Occupied())
-  for rowIndex, row := range m.seats {
-    for colIndex, status := range row {
-      if status != SEAT_FLOOR {
-        n := occupied(m, rowIndex, colIndex)
-        // Some empty seats may become occupied.
-        if status == SEAT_EMPTY && sit(m, n) {
-          newOccupied = append(newOccupied, Coordinate{rowIndex, colIndex})
-        // Some .
-        } else if status == SEAT_OCCUPIED && stand(m, n) {
-          newEmpty = append(newEmpty, Coordinate{rowIndex, colIndex})
-        }
+  newOccupied := make([]util.Point, 0, m.Empty())
+  newEmpty := make([]util.Point, 0, m.Occupied())
+  m.seats.Each(func(pos util.Point, status byte) {
+    if status != SEAT_FLOOR {
+      n := occupied(m, pos)
+      // Some empty seats may become occupied.
+      if status == SEAT_EMPTY && sit(m, n) {
+        newOccupied = append(newOccupied, pos)
+      // Some occupied seats may become empty.
+      } else if status == SEAT_OCCUPIED && stand(m, n) {
+        newEmpty = append(newEmpty, pos)
       }
     }
-  }
+  })
   // Commit seat changes all at once.
   for _, oPos := range newOccupied {
-    m.seats[oPos.row][oPos.col] = SEAT_OCCUPIED
+    m.seats.Set(oPos, SEAT_OCCUPIED)
   }
   for _, ePos := range newEmpty {
-    m.seats[ePos.row][ePos.col] = SEAT_EMPTY
+    m.seats.Set(ePos, SEAT_EMPTY)
   }
   // Update counts.
   m.nOccupied += len(newOccupied) - len(newEmpty)
@@ -107,47 +78,26 @@ func (m *SeatMap) fill(occupied func(*SeatMap, int, int) int,
   return len(newOccupied) > 0 || len(newEmpty) > 0
 }
 
-func (m *SeatMap) countVisibleFrom(row int, col int, rd int, cd int) (n int) {
-  pos := Coordinate{row + rd, col + cd}
-  for ; m.At(&pos) == SEAT_FLOOR;
-        pos.Add(rd, cd) {
-  }
-  if m.At(&pos) == SEAT_OCCUPIED {
-    return 1
-  }
-  return 0
-}
-
 // Number of visible (line-of-sight) occupied seats.
-func (m *SeatMap) nVisible(row int, col int) (n int) {
-  // Cardinal directions.
-  n += m.countVisibleFrom(row, col,  1,  0) // down
-  n += m.countVisibleFrom(row, col, -1,  0) // up
-  n += m.countVisibleFrom(row, col,  0, -1) // left
-  n += m.countVisibleFrom(row, col,  0,  1) // right
-  // Diagonals in all four directions.
-  n += m.countVisibleFrom(row, col, -1, -1) // upper left
-  n += m.countVisibleFrom(row, col, -1,  1) // upper right
-  n += m.countVisibleFrom(row, col,  1, -1) // lower left
-  n += m.countVisibleFrom(row, col,  1,  1) // lower right
+func (m *SeatMap) nVisible(pos util.Point) (n int) {
+  // All eight directions, including diagonals.
+  for _, step := range util.Directions8 {
+    _, seat, _ := m.seats.LineOfSight(pos, step,
+      func(c byte) bool { return c == SEAT_FLOOR })
+    if seat == SEAT_OCCUPIED {
+      n++
+    }
+  }
   return n
 }
 
 // Number of adjacent occupied seats.
-func (m *SeatMap) nAdjacent(seatRow int, seatCol int) (n int) {
-  for rowIndex := seatRow - 1;
-      rowIndex < seatRow + 2 && rowIndex < len(m.seats);
-      rowIndex++ {
-    for colIndex := seatCol - 1;
-        colIndex < seatCol + 2 && colIndex < m.width;
-        colIndex++ {
-      if (rowIndex >= 0 && colIndex >= 0 && (
-          m.seats[rowIndex][colIndex] == SEAT_OCCUPIED && (
-            rowIndex != seatRow || colIndex != seatCol))) {
-        n++
-      }
+func (m *SeatMap) nAdjacent(pos util.Point) (n int) {
+  m.seats.Neighbors(pos, util.Directions8, func(_ util.Point, seat byte) {
+    if seat == SEAT_OCCUPIED {
+      n++
     }
-  }
+  })
   return n
 }
 
@@ -187,9 +137,9 @@ func (m *SeatMap) Empty() int { return m.nEmpty }
 // Number of occupied seats.
 func (m *SeatMap) Occupied() int { return m.nOccupied }
 
-func Part1(lines []string) (int, int) {
+func Part1(initial *SeatMap) (int, int) {
   // Fill until nothing changes.
-  seatMap := NewSeatMap(lines)
+  seatMap := initial.Clone()
   nSteps := 0
   for seatMap.FillAdjacent() {
     nSteps++
@@ -197,9 +147,9 @@ func Part1(lines []string) (int, int) {
   return nSteps, seatMap.Occupied()
 }
 
-func Part2(lines []string) (int, int) {
+func Part2(initial *SeatMap) (int, int) {
   // Fill until nothing changes.
-  seatMap := NewSeatMap(lines)
+  seatMap := initial.Clone()
   nSteps := 0
   for seatMap.FillVisible() {
     nSteps++
@@ -207,19 +157,43 @@ func Part2(lines []string) (int, int) {
   return nSteps, seatMap.Occupied()
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  lines, err := util.ReadLinesFromFile(input_path)
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 11,
+    Title: "Seating System",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"37", "26"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  lines, err := util.ReadLines(r.Open())
+  if err != nil {
+    return err
+  }
+  seatMap, err := NewSeatMap(lines)
   if err != nil {
     return err
   }
+  r.Parsed()
 
-  aSteps, aOccupied := Part1(lines)
-  fmt.Printf("After %d adjacency steps, there are %d occupied seats.\n",
-    aSteps, aOccupied)
+  if r.Want(1) {
+    aSteps, aOccupied := Part1(seatMap)
+    r.Answer(1, "Occupied seats by adjacency", aOccupied,
+      fmt.Sprintf("after %d steps", aSteps))
+  }
 
-  vSteps, vOccupied := Part2(lines)
-  fmt.Printf("After %d visibility steps, there are %d occupied seats.\n",
-    vSteps, vOccupied)
+  if r.Want(2) {
+    vSteps, vOccupied := Part2(seatMap)
+    r.Answer(2, "Occupied seats by visibility", vOccupied,
+      fmt.Sprintf("after %d steps", vSteps))
+  }
 
   return nil
 }
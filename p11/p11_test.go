@@ -0,0 +1,11 @@
+package p11
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 11) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 11) }
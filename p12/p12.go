@@ -1,17 +1,19 @@
 package p12
 
 import (
+  "context"
+  _ "embed"
   "fmt"
   "io"
   "os"
   "bufio"
   "strconv"
   "errors"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
-var gVerbose = false
-
 type Direction struct {
   action byte
   value int
@@ -24,19 +26,25 @@ var Headings = map[byte]int { 'E': 1, 'N': 2, 'W': 3, 'S': 4 }
 // Left is clockwise (E->N->W->S) while right is counter-clockwise.
 var Rotations = map[byte]int { 'L': 1, 'R': -1 }
 
-// Parse a Direction from its string representation.
+// Parse a direction like "F10". An invalid one is reported as a
+// util.ParseError, without a line.
 func NewDirection(input string) (Direction, error) {
+  if len(input) < 2 {
+    return Direction{}, util.NewParseError(0, 1, input,
+      errors.New("invalid direction"))
+  }
   action := input[0]
   if Headings[action] == 0 && Rotations[action] == 0 && action != 'F' {
-    return Direction{}, errors.New(fmt.Sprintf("invalid action '%c'", action))
+    return Direction{}, util.NewParseError(0, 1, input[:1],
+      errors.New("invalid action"))
   }
   value, err := strconv.Atoi(input[1:])
   if err != nil {
-    return Direction{}, err
+    return Direction{}, util.NewParseError(0, 2, input[1:], err)
   }
   if Rotations[action] != 0 && (value % 90 != 0) {
-    return Direction{}, errors.New(fmt.Sprintf(
-      "non-cardinal rotation at '%s'", input))
+    return Direction{}, util.NewParseError(0, 2, input[1:],
+      errors.New("non-cardinal rotation"))
   }
   return Direction{action, value}, nil
 }
@@ -49,13 +57,21 @@ type Boat struct {
   // Relative position of the waypoint. Used for waypoint travel.
   wayLat int
   wayLong int
+
+  // Each move is logged here at trace level, if not nil.
+  log *logging.Logger
 }
 
 
 
 // You Must Build A Boat.
 func NewBoat(lat int, long int, head int, wayLat int, wayLong int) *Boat {
-  return &Boat{lat, long, head, wayLat, wayLong}
+  return &Boat{lat, long, head, wayLat, wayLong, nil}
+}
+
+// Log each move of the boat to log at trace level.
+func (b *Boat) SetLogger(log *logging.Logger) {
+  b.log = log
 }
 
 // Reset the position of the boat.
@@ -152,8 +168,8 @@ func (b *Boat) Move(d Direction) {
   if heading != 0 {
     // Move the waypoint with magnitude and heading.
     b.lat, b.long = move(b.lat, b.long, d.value, heading - 1)
-    if gVerbose {
-      fmt.Printf("Moving %s by %d to (%s, %s)\n",
+    if b.log.Enabled(logging.Trace) {
+      b.log.Tracef("Moving %s by %d to (%s, %s)",
         HeadingStrings[heading-1], d.value, b.LatStr(), b.LongStr())
     }
   } else {
@@ -161,15 +177,14 @@ func (b *Boat) Move(d Direction) {
     rotation := Rotations[d.action]
     if rotation != 0 {
       b.head = rotate(b.head, rotation * (d.value / 90))
-      if gVerbose {
-        fmt.Printf("Turning %c by %d => %s\n",
-          d.action, d.value, b.HeadStr())
+      if b.log.Enabled(logging.Trace) {
+        b.log.Tracef("Turning %c by %d => %s", d.action, d.value, b.HeadStr())
       }
     } else {
       // Move the boat along its current heading.
       b.lat, b.long = move(b.lat, b.long, d.value, b.head)
-      if gVerbose {
-        fmt.Printf("Moving %s by %d to (%s, %s)\n",
+      if b.log.Enabled(logging.Trace) {
+        b.log.Tracef("Moving %s by %d to (%s, %s)",
           b.HeadStr(), d.value, b.LatStr(), b.LongStr())
       }
     }
@@ -244,10 +259,10 @@ func ReadDirections(input io.Reader) ([]Direction, error) {
   scanner := bufio.NewScanner(input)
   scanner.Split(bufio.ScanLines)
   directions := make([]Direction, 0, 768)
-  for scanner.Scan() {
+  for line := 1; scanner.Scan(); line++ {
     direction, err := NewDirection(scanner.Text())
     if err != nil {
-      return directions, err
+      return directions, util.AtLine(err, line)
     }
     directions = append(directions, direction)
   }
@@ -263,28 +278,49 @@ func ReadDirectionsFromFile(path string) ([]Direction, error) {
   return ReadDirections(file)
 }
 
-func printBoat(boat *Boat, fromLat int, fromLong int) {
-  fmt.Println("New position and heading:", boat.Str())
-  fmt.Printf("Manhattan distance from start: %d\n",
-    boat.L1Distance(fromLat, fromLong))
+func reportBoat(r *registry.Run, part int, boat *Boat,
+                fromLat int, fromLong int) {
+  r.Answer(part, "Manhattan distance from start",
+    boat.L1Distance(fromLat, fromLong),
+    "New position and heading: " + boat.Str())
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
-  directions, err := ReadDirectionsFromFile(input_path)
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 12,
+    Title: "Rain Risk",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"25", "286"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  directions, err := ReadDirections(r.Open())
   if err != nil {
     return err
   }
+  r.Parsed()
 
   // Part 1 -- follow directions using turtle mechanics.
   boat := NewBoat(/*pos:*/ 0, 0, /*head:*/0/*E*/, /*waypoint:*/ 1/*N*/, 10/*E*/)
-  boat.Follow(directions)
-  printBoat(boat, 0, 0)
+  boat.SetLogger(r.Log.Sub("Boat"))
+  if r.Want(1) {
+    boat.Follow(directions)
+    reportBoat(r, 1, boat, 0, 0)
+  }
 
   // Part 2 -- follow directions using waypoint mechanics.
-  boat.Set(0, 0)
-  boat.FollowWaypoint(directions)
-  printBoat(boat, 0, 0)
+  if r.Want(2) {
+    boat.Set(0, 0)
+    boat.FollowWaypoint(directions)
+    reportBoat(r, 2, boat, 0, 0)
+  }
 
   return nil
 }
@@ -0,0 +1,39 @@
+package p12
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 12) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 12) }
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"",
+	"N",
+	"X10",
+	"Nx",
+}
+
+func TestNewDirectionMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := NewDirection(input); err == nil {
+			t.Errorf("NewDirection(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzNewDirection(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	for _, line := range registrytest.InputLines(f, 12) {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, input string) {
+		NewDirection(input)
+	})
+}
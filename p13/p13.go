@@ -2,16 +2,23 @@
 package p13
 
 import (
+  "context"
+  _ "embed"
+  "errors"
   "fmt"
   "os"
   "io"
   "bufio"
   "strconv"
   "strings"
+  "math"
+  "sort"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
+  "github.com/fritzr/advent2020/util"
+  "github.com/fritzr/advent2020/util/numtheory"
 )
 
-var gVerbose = false
-
 type BusSchedule struct {
   // List of bus IDs in the same order as the input.
   buses []int
@@ -29,10 +36,16 @@ func NewBusSchedule(sched string) (*BusSchedule, error) {
       if err != nil {
         return nil, err
       }
+      if busId <= 0 {
+        return nil, fmt.Errorf("invalid bus ID %d", busId)
+      }
       b.buses = append(b.buses, busId)
       b.busOffsets[busId] = index
     }
   }
+  if len(b.buses) == 0 {
+    return nil, errors.New("no buses in the schedule")
+  }
   return b, nil
 }
 
@@ -44,77 +57,43 @@ func (b *BusSchedule) NextAvailable(time int64) map[int]int64 {
   return nextAvailable
 }
 
-// Compute the parameter T for integer n and m such that:
-//   A * n = T and = B * m = T + O.
+// Find the earliest time at which each bus departs at its offset.
 //
-// In other words,
-//   A * n = B * m - O.
+// Each bus B at offset O departs at T + O, so T = -O modulo B: the Chinese
+// remainder theorem finds T. Returns an error if no time works.
 //
-// This function returns n. To obtain T itself, multiply the result by A.
-func offsetMatch(A int64, B int64, O int64) int64 {
-  Q := O / A
-  O = O % A
-
-  // In the degenerate case where the desired offset is zero,
-  // we can simply use n = B and m = A such that A * B = T = B * A.
-  // Of course, with a non-zero offset divisible by A, we still need to
-  // subtract by the quotient O / A.
-  if O == 0 {
-    return B - Q
-  }
-
-  // First find the quotient q and remainder r w.r.t B.
-  // We will further decompose A * n into k * (B + r).
-  q := B / A
-  r := B % A
-
-  // Find a = k * r = O mod A, the first multiple of r congruent to O modulo A.
-  // By definition, there exists some c for which A * c = k * r - O.
-  // Solving for k, we seek the first integer which satisfies k * r = O + A * c
-  // or in other words, (O + A * c) is divisible by r.
-  c := int64(1)
-  C := A
-  for (O + C) % r != 0 {
-    C += A
-    c++
+// The constraint for each bus is logged to log at debug level; log may be nil.
+func (b *BusSchedule) ConstrainedTime(log *logging.Logger) (int64, error) {
+  residues := make([]int64, len(b.buses))
+  moduli := make([]int64, len(b.buses))
+  for index, busId := range b.buses {
+    residues[index] = -int64(b.busOffsets[busId])
+    moduli[index] = int64(busId)
+    log.Debugf("  T = %d (mod %d)",
+      numtheory.Mod(residues[index], moduli[index]), busId)
   }
-  // So c := C / A, or C := A * c, and by our formula:
-  k := (O + C) / r
-
-  // Now we have n = |_B / A_| * k + |_B / A_| - |_O / A_|,
-  // or more simply n = q * k + c - Q.
-  // Roughly A * n = B * k + C - O.
-  n := q * k + c - Q
-  return A * n
+  t, _, err := numtheory.CRT(residues, moduli)
+  return t, err
 }
 
-func (b *BusSchedule) ConstrainedTime() int64 {
-  lcm := int64(b.buses[0])
-  t := int64(0)
-
-  // Find the sub-result t for A and B which defines the sequence:
-  //
-  //   { t, t + lcm(A, B), ..., t + lcm(A, B) * n }
-  //
-  for _, busId := range b.buses[1:] {
-    if gVerbose {
-      fmt.Printf("  %d + %d * n = T\n", t, lcm )
-      fmt.Printf("  %d + %d * n = T\n", t + int64(b.busOffsets[busId]), busId)
-      fmt.Printf("===================\n")
-    }
-    t += offsetMatch(lcm, int64(busId), t + int64(b.busOffsets[busId]))
-    lcm *= int64(busId)
-  }
+// How many candidate times to try between checks for cancellation.
+const checkInterval = 1 << 16
 
-  return t
-}
-
-func (b *BusSchedule) ConstrainedTimeBruteForce(maxIterations int64) int64 {
+// Like ConstrainedTime, but tries every departure of the first bus in turn.
+//
+// Returns -1 if no time matches within maxIterations departures. If the
+// context is cancelled first, returns an error saying how far it got.
+func (b *BusSchedule) ConstrainedTimeBruteForce(ctx context.Context,
+    maxIterations int64) (int64, error) {
   // Find the time t for which bus x departs at t + busOffsets[x] (for all x).
   bus0 := int64(b.buses[0])
   t := bus0
   n := int64(1)
   for n < maxIterations + 1 {
+    if n % checkInterval == 0 && ctx.Err() != nil {
+      return -1, fmt.Errorf("stopped after %d iterations (t=%d): %w",
+        n - 1, t, ctx.Err())
+    }
     valid := true
     /*
     if n == int64(152683) {
@@ -134,27 +113,39 @@ func (b *BusSchedule) ConstrainedTimeBruteForce(maxIterations int64) int64 {
       }
     }
     if valid {
-      return t
+      return t, nil
     }
     n++
     t += bus0
   }
-  return -1
+  return -1, nil
 }
 
+// Read the timestamp from the first line and the schedule from the second.
+// A missing or invalid line is reported as a util.ParseError.
 func ReadSchedule(input io.Reader) (timestamp int, s *BusSchedule, err error) {
   scanner := bufio.NewScanner(input)
   scanner.Split(bufio.ScanLines)
-  if scanner.Scan() {
-    timestamp, err = strconv.Atoi(scanner.Text())
+  if !scanner.Scan() {
+    if err = scanner.Err(); err == nil {
+      err = util.AtLine(errors.New("expected a timestamp"), 1)
+    }
+    return 0, nil, err
   }
-  if err == nil && scanner.Scan() {
-    s, err = NewBusSchedule(scanner.Text())
+  if timestamp, err = strconv.Atoi(scanner.Text()); err != nil {
+    return 0, nil, util.NewParseError(1, 1, scanner.Text(), err)
   }
-  if err == nil {
-    err = scanner.Err()
+  if !scanner.Scan() {
+    if err = scanner.Err(); err == nil {
+      err = util.AtLine(
+        errors.New("expected a schedule after the timestamp"), 2)
+    }
+    return timestamp, nil, err
+  }
+  if s, err = NewBusSchedule(scanner.Text()); err != nil {
+    return timestamp, nil, util.AtLine(err, 2)
   }
-  return timestamp, s, err
+  return timestamp, s, scanner.Err()
 }
 
 func ReadScheduleFromFile(path string) (int, *BusSchedule, error) {
@@ -166,37 +157,128 @@ func ReadScheduleFromFile(path string) (int, *BusSchedule, error) {
   return ReadSchedule(file)
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
-  timestamp, schedule, err := ReadScheduleFromFile(input_path)
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 13,
+    Title: "Shuttle Search",
+    Parts: 2,
+    Options: []registry.Option{
+      {Name: "brute-force", Default: false,
+        Usage: "solve part 2 by trying every departure of the first bus " +
+          "(this takes a very long time; see --timeout)"},
+    },
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"295", "1068781"}},
+    },
+    Main: Main,
+    Repl: Repl,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  bruteForce := r.Bool("brute-force")
+  timestamp, schedule, err := ReadSchedule(r.Open())
   if err != nil {
     return err
   }
+  r.Parsed()
 
   // Part 1: find the earliest bus after the given timestamp.
-  time := int64(timestamp)
-  earliestBus := -1
-  earliestWaitTime := int64(-1)
-  nextAvailable := schedule.NextAvailable(time)
-  for nextBus, nextTime := range nextAvailable {
-    if verbose {
-      fmt.Printf("  %d arrives next at %d\n", nextBus, nextTime)
-    }
-    wait := nextTime - time
-    if earliestWaitTime < 0 || wait < earliestWaitTime {
-      earliestBus = nextBus
-      earliestWaitTime = wait
+  if r.Want(1) {
+    time := int64(timestamp)
+    earliestBus := -1
+    earliestWaitTime := int64(-1)
+    nextAvailable := schedule.NextAvailable(time)
+    for nextBus, nextTime := range nextAvailable {
+      r.Log.Debugf("  %d arrives next at %d", nextBus, nextTime)
+      wait := nextTime - time
+      if earliestWaitTime < 0 || wait < earliestWaitTime {
+        earliestBus = nextBus
+        earliestWaitTime = wait
+      }
     }
-  }
 
-  earliestBusTime := nextAvailable[earliestBus]
-  fmt.Printf("Next bus after %d is %d, arriving at %d (wait time %d).\n",
-    time, earliestBus, earliestBusTime, earliestWaitTime)
-  fmt.Printf("    %d x %d = %d\n",
-    earliestBus, earliestWaitTime, int64(earliestBus)*earliestWaitTime)
+    earliestBusTime := nextAvailable[earliestBus]
+    r.Answer(1, "Earliest bus ID times wait time",
+      int64(earliestBus)*earliestWaitTime,
+      fmt.Sprintf("Next bus after %d is %d, arriving at %d (wait time %d).",
+        time, earliestBus, earliestBusTime, earliestWaitTime))
+  }
 
   // Part 2: find timestamp which matches the scheduled wait times.
-  constrainedTime := schedule.ConstrainedTime()
-  fmt.Printf("Schedule matches constraints starting at %d.\n", constrainedTime)
+  if !r.Want(2) {
+    return nil
+  }
+  var constrainedTime int64
+  if bruteForce {
+    constrainedTime, err = schedule.ConstrainedTimeBruteForce(ctx, math.MaxInt64 - 1)
+    if err != nil {
+      return err
+    }
+  } else {
+    constrainedTime, err = schedule.ConstrainedTime(r.Log)
+    if err != nil {
+      return err
+    }
+  }
+  r.Answer(2, "Earliest time matching the schedule constraints",
+    constrainedTime)
   return nil
 }
+
+func Repl(ctx context.Context, r *registry.Run) ([]registry.Command, error) {
+  timestamp, schedule, err := ReadSchedule(r.Open())
+  if err != nil {
+    return nil, err
+  }
+  return []registry.Command{
+    {Name: "buses", Usage: "list the buses with their offsets in the schedule",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        for _, busId := range schedule.buses {
+          fmt.Fprintf(w, "  bus %d at offset %d\n",
+            busId, schedule.busOffsets[busId])
+        }
+        return nil
+      }},
+    {Name: "next", Args: "[TIMESTAMP]", Usage: fmt.Sprintf("list when each " +
+      "bus next departs after TIMESTAMP (%d)", timestamp),
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        time := int64(timestamp)
+        if len(args) > 1 {
+          return registry.ErrUsage
+        } else if len(args) == 1 {
+          var err error
+          if time, err = strconv.ParseInt(args[0], 10, 64); err != nil {
+            return registry.ErrUsage
+          }
+        }
+        nextAvailable := schedule.NextAvailable(time)
+        busIds := make([]int, 0, len(nextAvailable))
+        for busId := range nextAvailable {
+          busIds = append(busIds, busId)
+        }
+        sort.Slice(busIds, func(i, j int) bool {
+          ti, tj := nextAvailable[busIds[i]], nextAvailable[busIds[j]]
+          return ti < tj || (ti == tj && busIds[i] < busIds[j])
+        })
+        for _, busId := range busIds {
+          fmt.Fprintf(w, "  bus %d at %d (wait %d)\n", busId,
+            nextAvailable[busId], nextAvailable[busId] - time)
+        }
+        return nil
+      }},
+    {Name: "constrained", Usage: "find the earliest time matching the " +
+      "offsets in the schedule",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        t, err := schedule.ConstrainedTime(r.Log)
+        if err != nil {
+          return err
+        }
+        fmt.Fprintln(w, t)
+        return nil
+      }},
+  }, nil
+}
@@ -0,0 +1,57 @@
+package p13
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 13) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 13) }
+
+func TestSolveMalformed(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"939\n",
+		"x\n7,13\n",
+		"939\nx,x\n",
+	} {
+		if err := registrytest.Solve(t, 13, input); err == nil {
+			t.Errorf("solved %q", input)
+		}
+	}
+}
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"",
+	"7,y,13",
+	"7,0",
+	"7,,13",
+	"x,x",
+}
+
+func TestNewBusScheduleMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := NewBusSchedule(input); err == nil {
+			t.Errorf("NewBusSchedule(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzNewBusSchedule(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	for _, line := range registrytest.InputLines(f, 13) {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, sched string) {
+		schedule, err := NewBusSchedule(sched)
+		if err != nil {
+			return
+		}
+		schedule.NextAvailable(939)
+	})
+}
@@ -1,16 +1,18 @@
 package p14
 
 import (
+  "context"
+  _ "embed"
   "fmt"
   "errors"
   "strings"
   "strconv"
   "math/bits"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
-var gVerbose = false
-
 type BitMemory interface {
   Write(address uint64, value uint64, setMask uint64, clrMask uint64)
   Sum() uint64
@@ -61,10 +63,13 @@ type FloatMemory struct {
   // writing "0b0*x1" and "0b0*0x" will both write to address 0b11.
   store map[uint64]uint64
   width int
+  log *logging.Logger
 }
 
-func NewFloatMemory(maskWidth int) *FloatMemory {
+// Each write is logged to log at trace level; log may be nil.
+func NewFloatMemory(maskWidth int, log *logging.Logger) *FloatMemory {
   m := new(FloatMemory)
+  m.log = log
   m.store = make(map[uint64]uint64)
   m.width = maskWidth
   return m
@@ -72,20 +77,14 @@ func NewFloatMemory(maskWidth int) *FloatMemory {
 
 func (m *FloatMemory) writeAll(addr uint64, value uint64, xBits []int) {
   if len(xBits) == 0 {
-    if gVerbose {
-      fmt.Printf("Writing %#x to %#x\n", value, addr)
-    }
+    m.log.Tracef("Writing %#x to %#x", value, addr)
     m.store[addr] = value
   } else {
     nextBit := uint64(1 << xBits[0])
     nextBits := xBits[1:]
-    if gVerbose {
-      fmt.Printf(".. clearing %#x\n", nextBit)
-    }
+    m.log.Tracef(".. clearing %#x", nextBit)
     m.writeAll(addr &^ nextBit, value, nextBits)
-    if gVerbose {
-      fmt.Printf(".. setting %#x\n", nextBit)
-    }
+    m.log.Tracef(".. setting %#x", nextBit)
     m.writeAll(addr | nextBit, value, nextBits)
   }
 }
@@ -105,15 +104,12 @@ func (m *FloatMemory) Write(addr uint64, value uint64, set uint64, clr uint64) {
     dontCare >>= bitIndex + 1
     nshift += bitIndex + 1
   }
-  if gVerbose {
-    fmt.Printf("write(%#x(%d), %#x(%d), %#x, %#x)",
-      addr, addr, value, value, set, clr)
-  }
   // We no longer clear according to the clear mask.
-  addr |= set
-  if gVerbose {
-    fmt.Printf(" (masked=%#x(%d))\n  Indexes = %v\n", addr, addr, xIndexes)
-  }
+  masked := addr | set
+  m.log.Tracef("write(%#x(%d), %#x(%d), %#x, %#x) (masked=%#x(%d))",
+    addr, addr, value, value, set, clr, masked, masked)
+  m.log.Tracef("  Indexes = %v", xIndexes)
+  addr = masked
   m.writeAll(addr, value, xIndexes)
 }
 
@@ -191,7 +187,9 @@ func parseMask(maskStr string) (setMask uint64, clrMask uint64, err error) {
       switch maskStr[strIndex] {
       case '1': setMask |= 1
       case '0': clrMask |= 1
-      default:  // don't care
+      case 'X': // don't care
+      default:
+        return 0, 0, fmt.Errorf("invalid mask bit %q", maskStr[strIndex])
       }
       // Don't shift out the last bit.
       if strIndex != len(maskStr) - 1 {
@@ -203,23 +201,35 @@ func parseMask(maskStr string) (setMask uint64, clrMask uint64, err error) {
   return setMask, clrMask, err
 }
 
+// Parse the program. An invalid instruction is reported as a
+// util.ParseError.
 func parseInsns(lines []string) ([]BitInsn, int, error) {
   fieldList := make([]BitInsn, 0, len(lines))
   var maskWidth int
-  for _, line := range lines {
+  for lineIndex, line := range lines {
+    lineNumber := lineIndex + 1
     fields := strings.Split(line, " = ")
     if len(fields) != 2 {
-      return fieldList, 0, errors.New(
-        fmt.Sprintf("wrong number of fields for instruction '%s'", line))
+      return fieldList, 0, util.NewParseError(lineNumber, 1, line,
+        errors.New("wrong number of fields for instruction"))
     }
+    valueColumn := len(fields[0]) + len(" = ") + 1
     // Memory write: mem[INDEX] = VALUE
     if strings.HasPrefix(fields[0], "mem[") {
+      if !strings.HasSuffix(fields[0], "]") || len(fields[0]) < 6 {
+        return fieldList, 0, util.NewParseError(lineNumber, 1, fields[0],
+          errors.New("malformed memory address"))
+      }
       indexStr := fields[0][4:len(fields[0])-1]
       index, err := strconv.Atoi(indexStr)
       if err != nil {
-        return fieldList, 0, err
+        return fieldList, 0, util.NewParseError(lineNumber, 5, indexStr, err)
       }
       value, err := strconv.Atoi(fields[1])
+      if err != nil {
+        return fieldList, 0, util.NewParseError(lineNumber, valueColumn,
+          fields[1], err)
+      }
       fieldList = append(fieldList,
         BitInsn{INSN_WRITE, uint64(index), uint64(value)})
     } else if fields[0] == "mask" {
@@ -229,32 +239,52 @@ func parseInsns(lines []string) ([]BitInsn, int, error) {
       }
       setMask, clrMask, err := parseMask(fields[1])
       if err != nil {
-        return fieldList, 0, err
+        return fieldList, 0, util.NewParseError(lineNumber, valueColumn,
+          fields[1], err)
       }
       fieldList = append(fieldList,
         BitInsn{INSN_MASK, setMask, clrMask})
     } else {
-      return fieldList, 0, errors.New(
-        fmt.Sprintf("unrecognized mnemonic '%s'", fields[0]))
+      return fieldList, 0, util.NewParseError(lineNumber, 1, fields[0],
+        errors.New("unrecognized mnemonic"))
     }
   }
   return fieldList, maskWidth, nil
 }
 
-func doExec(sys *BitSystem, insns []BitInsn) error {
+func doExec(r *registry.Run, part int, label string,
+            sys *BitSystem, insns []BitInsn) error {
   err := sys.ExecAll(insns)
   if err != nil {
     return err
   }
 
-  fmt.Printf("The sum of %d memory words is %d.\n",
-    sys.mem.Addresses(), sys.MemorySum())
+  r.Answer(part, label, sys.MemorySum(),
+    fmt.Sprintf("summed over %d memory words", sys.mem.Addresses()))
   return nil
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
-  lines, err := util.ReadLinesFromFile(input_path)
+//go:embed example1.txt
+var example1 string
+
+//go:embed example2.txt
+var example2 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 14,
+    Title: "Docking Data",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Part: 1, Answers: []string{"165"}},
+      {Input: example2, Part: 2, Answers: []string{"", "208"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  lines, err := util.ReadLines(r.Open())
   if err != nil {
     return err
   }
@@ -263,23 +293,26 @@ func Main(input_path string, verbose bool, args []string) error {
   if err2 != nil {
     return err2
   }
+  r.Parsed()
 
   // Part 1: standard flat memory.
-  flat := NewFlatMemory()
-  s1 := NewBitSystem(flat)
-  fmt.Printf("Flat memory: ")
-  err = doExec(s1, insns)
-  if err != nil {
-    return err
+  if r.Want(1) {
+    flat := NewFlatMemory()
+    s1 := NewBitSystem(flat)
+    err = doExec(r, 1, "Sum of flat memory", s1, insns)
+    if err != nil {
+      return err
+    }
   }
 
   // Part 2: special floating-address memory.
-  floating := NewFloatMemory(maskWidth)
-  s2 := NewBitSystem(floating)
-  fmt.Printf("Floating memory: ")
-  err = doExec(s2, insns)
-  if err != nil {
-    return err
+  if r.Want(2) {
+    floating := NewFloatMemory(maskWidth, r.Log.Sub("FloatMemory"))
+    s2 := NewBitSystem(floating)
+    err = doExec(r, 2, "Sum of floating memory", s2, insns)
+    if err != nil {
+      return err
+    }
   }
 
   return nil
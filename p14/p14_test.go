@@ -0,0 +1,46 @@
+package p14
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 14) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 14) }
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"mem[ = 1",
+	"mask = 12",
+	"mem[8] = x",
+	"mem[8] 11",
+	"jmp +1",
+}
+
+func TestParseInsnsMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, _, err := parseInsns([]string{input}); err == nil {
+			t.Errorf("parseInsns(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzParseInsns(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	f.Add(string(registrytest.InputData(f, 14)))
+	lines := registrytest.InputLines(f, 14)
+	if len(lines) > 10 {
+		lines = lines[:10]
+	}
+	for _, line := range lines {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		parseInsns(strings.Split(data, "\n"))
+	})
+}
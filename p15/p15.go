@@ -1,38 +1,46 @@
 package p15
 
 import (
-  "io/ioutil"
-  "strings"
-  "strconv"
+  "context"
+  _ "embed"
   "errors"
+  "strconv"
+  "strings"
   "fmt"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
-var gVerbose = false
+// How many turns to play between checks for cancellation.
+const checkInterval = 1 << 16
 
-func Usage() {
-  fmt.Println("usage: advent2020 [main opts...] [-n turns]")
-  fmt.Println()
-  fmt.Println("Run the game for the specified number of turns.")
-  fmt.Println("If not given, run once with 2020 turns and once with 30000000.")
-}
+// Return the number spoken on the last round.
+//
+// If the context is cancelled first, return an error saying how many turns
+// were played. Each turn is logged to log at trace level; log may be nil.
+func RambunctiousRecitation(ctx context.Context, init []int, rounds int,
+    log *logging.Logger) (int, error) {
+  trace := log.Enabled(logging.Trace)
 
-func RambunctiousRecitation(init []int, rounds int) int {
   // Initialize the age map.
   lastSpoken := make(map[int]int)
   for turn, startNumber := range init {
     lastSpoken[startNumber] = turn + 1 // never assign turn 0
-    if gVerbose {
-      fmt.Printf("  Turn %4d: %d\n", turn + 1, startNumber)
+    if trace {
+      log.Tracef("  Turn %4d: %d", turn + 1, startNumber)
     }
   }
 
   last := init[len(init) - 1]
   next := 0 // assuming the input numbers are all unique
   for turn := len(lastSpoken) + 1; turn <= rounds; turn++ {
-    if gVerbose {
-      fmt.Printf("  Turn %4d: %d\n", turn, next)
+    if turn % checkInterval == 0 && ctx.Err() != nil {
+      return last, fmt.Errorf("stopped after %d of %d turns: %w",
+        turn - 1, rounds, ctx.Err())
+    }
+    if trace {
+      log.Tracef("  Turn %4d: %d", turn, next)
     }
     last = next
     if lastSpoken[last] == 0 {
@@ -44,52 +52,95 @@ func RambunctiousRecitation(init []int, rounds int) int {
     }
     lastSpoken[last] = turn
   }
-  return last
+  return last, nil
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
-  data, err := ioutil.ReadFile(input_path)
-  if err != nil {
-    return err
+// Parse the starting numbers, like "0,3,6". The game assumes they are
+// distinct. An invalid number is reported as a util.ParseError.
+func ParseStartingNumbers(data string) ([]int, error) {
+  line := strings.TrimRight(data, "\r\n")
+  fields := util.SplitAt(line, ",")
+  numbers := make([]int, len(fields))
+  seen := make(map[int]bool, len(fields))
+  for index, field := range fields {
+    number, err := strconv.Atoi(field.Text)
+    if err != nil {
+      return nil, util.NewParseError(1, field.Offset + 1, field.Text, err)
+    }
+    if seen[number] {
+      return nil, util.NewParseError(1, field.Offset + 1, field.Text,
+        errors.New("repeated starting number"))
+    }
+    seen[number] = true
+    numbers[index] = number
   }
+  return numbers, nil
+}
 
-  fields := strings.Split(strings.Trim(string(data), "\n"), ",")
-  numbers, err := util.FieldsToInts(fields)
+//go:embed example1.txt
+var example1 string
+
+//go:embed example2.txt
+var example2 string
+
+//go:embed example3.txt
+var example3 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 15,
+    Title: "Rambunctious Recitation",
+    Parts: 2,
+    Help: "Without -n, play once with 2020 turns and once with 30000000.",
+    Options: []registry.Option{
+      {Name: "n", Usage: "play the game for `TURNS` turns", Default: 0,
+        Check: registry.Positive},
+    },
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"436", "175594"}},
+      {Input: example2, Answers: []string{"1", "2578"}},
+      {Input: example3, Answers: []string{"1836", "362"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  numbers, err := ParseStartingNumbers(string(r.Data))
   if err != nil {
     return err
   }
+  r.Parsed()
 
-  if len(args) > 0 {
-    if args[0] == "-h" || args[0] == "--help" {
-      Usage()
-      return nil
-    }
-    if args[0] == "-n" {
-      if len(args) < 2 {
-        return errors.New("option -n requires an argument")
-      }
-      nTurns, err := strconv.Atoi(args[1])
-      if err != nil {
-        return err
-      }
-
-      // Just do the requested amount.
-      fmt.Printf("The %d-th number spoken is %d.\n", nTurns,
-        RambunctiousRecitation(numbers, nTurns))
-      return nil
+  // Just do the requested amount.
+  if nTurns := r.Int("n"); nTurns > 0 {
+    spoken, err := RambunctiousRecitation(ctx, numbers, nTurns, r.Log)
+    if err != nil {
+      return err
     }
+    r.Answer(0, fmt.Sprintf("The %d-th number spoken", nTurns), spoken)
+    return nil
   }
 
   // Part 1: 2020 turns
-  nTurns := 2020
-  fmt.Printf("The %d-th number spoken is %d.\n", nTurns,
-    RambunctiousRecitation(numbers, nTurns))
+  if r.Want(1) {
+    nTurns := 2020
+    spoken, err := RambunctiousRecitation(ctx, numbers, nTurns, r.Log)
+    if err != nil {
+      return err
+    }
+    r.Answer(1, fmt.Sprintf("The %d-th number spoken", nTurns), spoken)
+  }
 
   // Part 2:
-  nTurns = 30000000
-  fmt.Printf("The %d-th number spoken is %d.\n", nTurns,
-    RambunctiousRecitation(numbers, nTurns))
+  if r.Want(2) {
+    nTurns := 30000000
+    spoken, err := RambunctiousRecitation(ctx, numbers, nTurns, r.Log)
+    if err != nil {
+      return err
+    }
+    r.Answer(2, fmt.Sprintf("The %d-th number spoken", nTurns), spoken)
+  }
 
   return nil
 }
@@ -0,0 +1,57 @@
+package p15
+
+import (
+	"context"
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 15) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 15) }
+
+func TestSolveMalformed(t *testing.T) {
+	for _, input := range []string{
+		"",
+		"0,3,x",
+		"0,3,0",
+		"0,,6",
+	} {
+		if err := registrytest.Solve(t, 15, input); err == nil {
+			t.Errorf("solved %q", input)
+		}
+	}
+}
+
+// Starting numbers the parser must reject.
+var malformed = []string{
+	"",
+	"\n",
+	"0,3,",
+	"0;3;6",
+	"0,3,0",
+}
+
+func TestParseStartingNumbersMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := ParseStartingNumbers(input); err == nil {
+			t.Errorf("ParseStartingNumbers(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzParseStartingNumbers(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	f.Add(string(registrytest.InputData(f, 15)))
+	f.Add("0,3,6\n")
+	f.Fuzz(func(t *testing.T, data string) {
+		numbers, err := ParseStartingNumbers(data)
+		if err != nil {
+			return
+		}
+		RambunctiousRecitation(context.Background(), numbers, 2020, nil)
+	})
+}
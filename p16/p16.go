@@ -1,16 +1,17 @@
 package p16
 
 import (
-  "io"
+  "context"
+  _ "embed"
   "fmt"
   "errors"
   "strconv"
   "strings"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
-var gVerbose = false
-
 type TicketField struct {
   name string
   ranges [][2]int
@@ -36,30 +37,34 @@ func (f *TicketField) IsValid(value int) bool {
   return false
 }
 
+// Parse a field like "class: 1-3 or 5-7". An invalid one is reported as a
+// util.ParseError, without a line.
 func parseTicketField(line string) (*TicketField, error) {
   // name: x-y or a-b...
   lr := strings.Split(line, ": ")
   if len(lr) != 2 {
-    return nil, errors.New("expected field name separator")
+    return nil, util.NewParseError(0, 1, line,
+      errors.New("expected field name separator"))
   }
   name := lr[0]
-  rangeStrings := strings.Split(lr[1], " or ")
-  if len(rangeStrings) == 0 {
-    return nil, errors.New("expected non-empty field range")
-  }
+  rangesStart := len(lr[0]) + len(": ")
+  rangeStrings := util.SplitAt(lr[1], " or ")
   ranges := make([][2]int, len(rangeStrings))
   for index, rangeString := range rangeStrings {
-    rangeFields := strings.Split(rangeString, "-")
+    column := rangesStart + rangeString.Offset + 1
+    rangeFields := strings.Split(rangeString.Text, "-")
     if len(rangeFields) != 2 {
-      return nil, errors.New("expected range separator")
+      return nil, util.NewParseError(0, column, rangeString.Text,
+        errors.New("expected range separator"))
     }
     min, err := strconv.Atoi(rangeFields[0])
     if err != nil {
-      return nil, err
+      return nil, util.NewParseError(0, column, rangeFields[0], err)
     }
     max, err := strconv.Atoi(rangeFields[1])
     if err != nil {
-      return nil, err
+      return nil, util.NewParseError(0, column + len(rangeFields[0]) + 1,
+        rangeFields[1], err)
     }
     ranges[index] = [2]int{min, max}
   }
@@ -73,101 +78,100 @@ func parseTicketFields(fieldsText string) (fields []*TicketField, err error) {
   for lineNumber, line := range lines {
     fields[lineNumber], err = parseTicketField(line)
     if err != nil {
-      break
+      return fields, util.AtLine(err, lineNumber + 1)
     }
   }
-  return fields, err
+  return fields, nil
 }
 
+// Read lines of integers separated by sep. An invalid integer is reported as
+// a util.ParseError relative to the start of the text.
 func ReadListOfInts(text string, sep string) (list [][]int, err error) {
   // Each line is a list of integers.
   lines := strings.Split(text, "\n")
   list = make([][]int, len(lines))
   for lineNumber, line := range lines {
-    list[lineNumber], err = util.FieldsToInts(strings.Split(line, sep))
-    if err != nil {
-      break
+    fields := util.SplitAt(line, sep)
+    list[lineNumber] = make([]int, len(fields))
+    for index, field := range fields {
+      list[lineNumber][index], err = strconv.Atoi(field.Text)
+      if err != nil {
+        return list, util.NewParseError(lineNumber + 1, field.Offset + 1,
+          field.Text, err)
+      }
     }
   }
-  return list, err
+  return list, nil
 }
 
 func parseTickets(ticketText string) ([][]int, error) {
   // Skip the first line.
   eolIndex := strings.IndexByte(ticketText, '\n')
   if eolIndex < 0 {
-    return nil, errors.New("expected end-of-line in ticket")
+    return nil, util.AtLine(errors.New("expected end-of-line in ticket"), 1)
   }
   // Obtain list of integer lists from the subsequent lines.
-  return ReadListOfInts(ticketText[eolIndex+1:], ",")
+  list, err := ReadListOfInts(ticketText[eolIndex+1:], ",")
+  return list, util.AtLine(err, 2)
 }
 
-func findValidTickets(fields []*TicketField, tickets [][]int) (
-    validTicketNumbers util.Set, errorRate int) {
-  validTicketNumbers = make(util.Set)
+// Each ticket is checked against every field, logged to log at trace level.
+func findValidTickets(fields []*TicketField, tickets [][]int,
+    log *logging.Logger) (validTicketNumbers util.Set[int], errorRate int) {
+  validTicketNumbers = util.NewSet[int]()
   for ticketNumber, ticket := range tickets {
-    if gVerbose {
-      fmt.Printf("[%d] ## %v\n", ticketNumber, ticket)
-    }
+    log.Tracef("[%d] ## %v", ticketNumber, ticket)
     allValid := true
     for _, value := range ticket {
       valid := false
       for _, fieldSpec := range fields {
         if fieldSpec.IsValid(value) {
-          if gVerbose {
-            fmt.Printf("     %d is valid for %s\n", value, fieldSpec.name)
-          }
+          log.Tracef("     %d is valid for %s", value, fieldSpec.name)
           valid = true
           break
-        } else if gVerbose {
-          fmt.Printf("     %d is invalid for %s\n", value, fieldSpec.name)
         }
+        log.Tracef("     %d is invalid for %s", value, fieldSpec.name)
       }
       if !valid {
         allValid = false
-        if gVerbose {
-          fmt.Printf("[%d] is invalid\n", ticketNumber)
-        }
+        log.Tracef("[%d] is invalid", ticketNumber)
         errorRate += value
       }
     }
     if allValid {
-      if gVerbose {
-        fmt.Printf("[%d] is valid\n", ticketNumber)
-      }
-      validTicketNumbers[ticketNumber] = true
+      log.Tracef("[%d] is valid", ticketNumber)
+      validTicketNumbers.Add(ticketNumber)
     }
   }
   return validTicketNumbers, errorRate
 }
 
-func identifyFields(fields []*TicketField, tickets [][]int, ticketIndexes util.Set)(
-    []string, error) {
+// Eliminated candidates are logged to log at trace level, and selected fields
+// at debug level.
+func identifyFields(fields []*TicketField, tickets [][]int,
+    ticketIndexes util.Set[int], log *logging.Logger) ([]string, error) {
   // Now identify which fields are which based on validity.
   // This structure maps field names to the field indexes which are possible.
   // Whenever a range constraint for that field is violated, we remove it from
   // the set of possible indexes.
-  possibleFieldIndexes := make(map[string]util.Set)
+  possibleFieldIndexes := make(map[string]util.Set[int])
   for _, field := range fields {
-    possibleFieldIndexes[field.name] = make(util.Set)
+    possibleFieldIndexes[field.name] = util.NewSet[int]()
     for index := 0; index < len(fields); index++ {
-      possibleFieldIndexes[field.name][index] = true
+      possibleFieldIndexes[field.name].Add(index)
     }
   }
 
   // Mask out the fields which are invalid by index.
-  for ticketNumber, _ := range ticketIndexes {
+  for _, ticketNumber := range ticketIndexes.Values() {
     ticket := tickets[ticketNumber]
     for fieldIndex, field := range ticket {
       for _, fieldSpec := range fields {
         if !fieldSpec.IsValid(field) {
-          delete(possibleFieldIndexes[fieldSpec.name], fieldIndex)
-          if gVerbose {
-            fmt.Printf(
-              "[%d]: %s cannot be [%d] because %d is invalid (now: %v)\n",
-              ticketNumber, fieldSpec.name, fieldIndex, field,
-              possibleFieldIndexes[fieldSpec.name])
-          }
+          possibleFieldIndexes[fieldSpec.name].Remove(fieldIndex)
+          log.Tracef("[%d]: %s cannot be [%d] because %d is invalid (now: %v)",
+            ticketNumber, fieldSpec.name, fieldIndex, field,
+            possibleFieldIndexes[fieldSpec.name])
         }
       }
     }
@@ -185,11 +189,9 @@ func identifyFields(fields []*TicketField, tickets [][]int, ticketIndexes util.S
     selectedNow := make(map[int]string)
     for fieldName, possibleIndexes := range possibleFieldIndexes {
       // Select the field if it is unique.
-      if len(possibleIndexes) == 1 {
-        for index, _ := range(possibleIndexes) {
-          if gVerbose {
-            fmt.Printf("selecting field [%d] for %s\n", index, fieldName)
-          }
+      if possibleIndexes.Len() == 1 {
+        for _, index := range possibleIndexes.Values() {
+          log.Debugf("selecting field [%d] for %s", index, fieldName)
           // Check we didn't just select it for a different field.
           if selectedNow[index] != "" {
             return fieldNames, errors.New(fmt.Sprintf(
@@ -203,11 +205,11 @@ func identifyFields(fields []*TicketField, tickets [][]int, ticketIndexes util.S
     // Remove the selected fields as possibilities for anyone else.
     for selectedIndex, fieldName := range selectedNow {
       for oldName, possibleIndexes := range possibleFieldIndexes {
-        if gVerbose && possibleIndexes[selectedIndex] {
-          fmt.Printf("[%d] was assigned to %s: no longer a candidate for %s\n",
+        if possibleIndexes.Has(selectedIndex) {
+          log.Tracef("[%d] was assigned to %s: no longer a candidate for %s",
             selectedIndex, fieldName, oldName)
         }
-        delete(possibleIndexes, selectedIndex)
+        possibleIndexes.Remove(selectedIndex)
       }
       // Then commit the selected index.
       fieldNames[selectedIndex] = fieldName
@@ -219,13 +221,13 @@ func identifyFields(fields []*TicketField, tickets [][]int, ticketIndexes util.S
   if selectedFields < nFields {
     var errorStr strings.Builder
     for fieldName, indexes := range possibleFieldIndexes {
-      if len(indexes) == 0 {
+      if indexes.Len() == 0 {
         errorStr.WriteString(fmt.Sprintf(
           "no matches for field '%s'\n", fieldName))
       } else {
         errorStr.WriteString(fmt.Sprintf(
           "multiple (%d) matches for field '%s': %v\n",
-          len(indexes), fieldName, indexes))
+          indexes.Len(), fieldName, indexes))
       }
     }
     return fieldNames, errors.New(errorStr.String())
@@ -235,81 +237,101 @@ func identifyFields(fields []*TicketField, tickets [][]int, ticketIndexes util.S
 }
 
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
-  groups, err := util.ReadFile(input_path,
-    func(input io.Reader) (interface{}, error) {
-      return util.ScanInput(input, util.ScanLineGroups)
-    })
-  if err != nil {
-    return err
-  }
+//go:embed example1.txt
+var example1 string
 
-  strGroups := groups.([]string)
-  if len(strGroups) != 3 {
-    return errors.New("invalid input format")
-  }
+//go:embed example2.txt
+var example2 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 16,
+    Title: "Ticket Translation",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Part: 1, Answers: []string{"71"}},
+      {Input: example2},
+    },
+    Main: Main,
+  })
+}
 
+func Main(ctx context.Context, r *registry.Run) error {
+  log := r.Log
   // Input is broken into {fields, my ticket, nearby tickets}.
-  fields, err := parseTicketFields(strGroups[0])
-  if err != nil {
+  var (
+    fields []*TicketField
+    myTickets, otherTickets [][]int
+    err error
+  )
+  groups := util.NewLineGroupScanner(r.Open())
+  for groups.Scan() {
+    switch groups.Count() {
+    case 1:
+      fields, err = parseTicketFields(groups.Text())
+    case 2:
+      myTickets, err = parseTickets(groups.Text())
+    case 3:
+      otherTickets, err = parseTickets(groups.Text())
+    }
+    if err != nil {
+      return util.AtLine(err, groups.Line())
+    }
+  }
+  if err = groups.Err(); err != nil {
     return err
   }
+  if groups.Count() != 3 {
+    return fmt.Errorf("expected 3 groups of lines, found %d", groups.Count())
+  }
 
-  if verbose {
+  if log.Enabled(logging.Debug) {
     for _, field := range fields {
-      fmt.Printf("%s:", field.name)
+      var ranges strings.Builder
       for _, extents := range field.ranges {
-        fmt.Printf("  %d-%d", extents[0], extents[1])
+        fmt.Fprintf(&ranges, "  %d-%d", extents[0], extents[1])
       }
-      fmt.Println()
+      log.Debugf("%s:%s", field.name, ranges.String())
     }
   }
-
-  otherTickets, err := parseTickets(strGroups[2])
-  if err != nil {
-    return err
-  }
-
-  myTickets, err := parseTickets(strGroups[1])
-  if err != nil {
-    return err
-  }
+  r.Parsed()
 
   // Part 1: filter out invalid tickets.
-  validTickets, errorRate := findValidTickets(fields, otherTickets)
+  validTickets, errorRate := findValidTickets(fields, otherTickets, log)
 
-  fmt.Printf("Ticket scanning error rate: %d\n", errorRate)
-  fmt.Printf("There were %d valid tickets.\n", len(validTickets))
+  r.Answer(1, "Ticket scanning error rate", errorRate,
+    fmt.Sprintf("There were %d valid tickets.", validTickets.Len()))
 
   // Part 2: find ticket fields. Assume our ticket(s) are valid.
+  if !r.Want(2) {
+    return nil
+  }
 
-  if verbose {
-    fmt.Printf("My tickets: ")
-    for _, myTicket := range myTickets {
-      fmt.Printf("# %v\n", myTicket)
-    }
+  for _, myTicket := range myTickets {
+    log.Debugf("My ticket: # %v", myTicket)
   }
 
   allTickets := append(otherTickets, myTickets...)
-  validTickets[len(allTickets)-1] = true
-  fieldNames, err := identifyFields(fields, allTickets, validTickets)
+  validTickets.Add(len(allTickets)-1)
+  fieldNames, err := identifyFields(fields, allTickets, validTickets, log)
   if err != nil {
     return err
   }
 
   // Identify the fields on my ticket(s).
-  fmt.Println("My ticket fields:")
   for _, myTicket := range myTickets {
     departureProd := 1
+    details := make([]string, 0, len(fieldNames) + 1)
+    details = append(details, "My ticket fields:")
     for index, name := range fieldNames {
       myValue := myTicket[index]
-      fmt.Printf("  [%2d] %s: %d\n", index, name, myValue)
+      details = append(details,
+        fmt.Sprintf("  [%2d] %s: %d", index, name, myValue))
       if strings.HasPrefix(name, "departure") {
         departureProd *= myValue
       }
     }
-    fmt.Printf("Product of 'departure' fields: %d\n", departureProd)
+    r.Answer(2, "Product of 'departure' fields", departureProd, details...)
   }
 
   return nil
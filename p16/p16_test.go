@@ -0,0 +1,64 @@
+package p16
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 16) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 16) }
+
+// Fields and tickets the parsers must reject.
+var (
+	malformedFields = []string{
+		"class 1-3 or 5-7",
+		"class: 1-x or 5-7",
+		"class: 3",
+		"class: 1-3 5-7",
+	}
+	malformedTickets = []string{
+		"nearby tickets:\n7,x,47",
+		"nearby tickets:\n7,,47",
+	}
+)
+
+func TestParseTicketFieldMalformed(t *testing.T) {
+	for _, input := range malformedFields {
+		if _, err := parseTicketField(input); err == nil {
+			t.Errorf("parseTicketField(%q) succeeded", input)
+		}
+	}
+}
+
+func TestParseTicketsMalformed(t *testing.T) {
+	for _, input := range malformedTickets {
+		if _, err := parseTickets(input); err == nil {
+			t.Errorf("parseTickets(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzParseTicketField(f *testing.F) {
+	for _, input := range malformedFields {
+		f.Add(input)
+	}
+	for _, line := range registrytest.InputLines(f, 16) {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		parseTicketField(line)
+	})
+}
+
+func FuzzParseTickets(f *testing.F) {
+	f.Add("nearby tickets:\n7,3,47\n40,4,50")
+	for _, input := range malformedTickets {
+		f.Add(input)
+	}
+	f.Add(string(registrytest.InputData(f, 16)))
+	f.Fuzz(func(t *testing.T, text string) {
+		parseTickets(text)
+	})
+}
@@ -1,15 +1,16 @@
 package p17
 
 import (
+  "context"
+  _ "embed"
   "fmt"
   "strconv"
   "strings"
-  "errors"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
-var gVerbose = false
-
 type pocketDimensionKey uint64
 
 type PocketDimension struct {
@@ -25,6 +26,8 @@ type PocketDimension struct {
   // The extents {min, max} of coordinates ever activated in each dimension.
   // This will grow monotonically away from zero.
   // extents [][2]int
+
+  log *logging.Logger
 }
 
 func sgn(val int) uint64 {
@@ -82,8 +85,11 @@ func pdRHash(hash pocketDimensionKey, ndim int) (coords []int) {
   return coords
 }
 
-func NewPocketDimension(ndim int) *PocketDimension {
+// Each step of the simulation is logged to log: the active cells at debug
+// level, and the state of every cell visited at trace level. log may be nil.
+func NewPocketDimension(ndim int, log *logging.Logger) *PocketDimension {
   d := new(PocketDimension)
+  d.log = log
   d.cells = make(map[pocketDimensionKey]bool)
   d.ndim = ndim
   // d.extents = make([][2]int, ndim)
@@ -221,11 +227,9 @@ func (d *PocketDimension) Simulate() {
   // These are all executed once after we visit the cells.
   exec := make(map[pocketDimensionKey]int)
 
-  var stateStr func(int) string
-  if gVerbose {
-    stateStr = func(state int) string {
-      return []string{"NONE", "STABLE", "ACTIVE", "INACTIVE", "PENDING"}[state]
-    }
+  trace := d.log.Enabled(logging.Trace)
+  stateStr := func(state int) string {
+    return []string{"NONE", "STABLE", "ACTIVE", "INACTIVE", "PENDING"}[state]
   }
 
   // Visit every active cell and their neighbors.
@@ -238,8 +242,9 @@ func (d *PocketDimension) Simulate() {
     if exec[activeHash] == 0 {
       exec[activeHash] = PENDING
 
-      if gVerbose {
-        fmt.Printf("  visiting   active %s (hash=%x)\n", coordStr(active), activeHash)
+      if trace {
+        d.log.Tracef("  visiting   active %s (hash=%x)", coordStr(active),
+          activeHash)
       }
 
       // Count active neighbors of the active cell.
@@ -254,8 +259,8 @@ func (d *PocketDimension) Simulate() {
         if exec[nHash] == 0 && !d.isActiveHash(nHash) {
           exec[nHash] = PENDING
 
-          if gVerbose {
-            fmt.Printf("    visiting inactive %s (hash=%x)\n",
+          if trace {
+            d.log.Tracef("    visiting inactive %s (hash=%x)",
               coordStr(n), nHash)
           }
 
@@ -272,8 +277,8 @@ func (d *PocketDimension) Simulate() {
           } else {
             exec[nHash] = STABLE
           }
-          if gVerbose {
-            fmt.Printf("    ... %x state: inactive => %s (n=%d)\n",
+          if trace {
+            d.log.Tracef("    ... %x state: inactive => %s (n=%d)",
               nHash, stateStr(exec[nHash]), nN)
           }
         }
@@ -285,8 +290,8 @@ func (d *PocketDimension) Simulate() {
       } else {
         exec[activeHash] = STABLE
       }
-      if gVerbose {
-        fmt.Printf("  ... %x state: active => %s (n=%d)\n",
+      if trace {
+        d.log.Tracef("  ... %x state: active => %s (n=%d)",
           activeHash, stateStr(exec[activeHash]), activeN)
       }
     }
@@ -312,8 +317,8 @@ func (d *PocketDimension) Simulate() {
 func (d *PocketDimension) SimulateN(steps int) {
   for step := 0; step < steps; step++ {
     d.Simulate()
-    if gVerbose {
-      fmt.Printf("After step %d, actives are:\n%s\n", step + 1, d.ActiveStr())
+    if d.log.Enabled(logging.Debug) {
+      d.log.Debugf("After step %d, actives are:\n%s", step + 1, d.ActiveStr())
     }
   }
 }
@@ -337,17 +342,16 @@ func (d *PocketDimension) GetExtents() [][2]int {
   return extents
 }
 
-func (d *PocketDimension) ActivatePlane(plane []string) {
-  for rowCoord, line := range plane {
-    for colCoord, c := range []byte(line) {
-      if c != '.' {
-        coord := make([]int, d.ndim)
-        coord[0] = rowCoord
-        coord[1] = colCoord
-        d.Activate(coord)
-      }
+// Activate the cells marked '#' in a plane of the first two dimensions.
+func (d *PocketDimension) ActivatePlane(plane *util.Grid[byte]) {
+  plane.Each(func(pos util.Point, c byte) {
+    if c == '#' {
+      coord := make([]int, d.ndim)
+      coord[0] = pos.Row
+      coord[1] = pos.Col
+      d.Activate(coord)
     }
-  }
+  })
 }
 
 // String representation for debugging.
@@ -367,64 +371,71 @@ func (d *PocketDimension) ActiveStr() string {
   return s.String()
 }
 
-func Usage() {
-  fmt.Println("usage: advent2020 17 [main opts...] [-n N]")
-  fmt.Println("")
-  fmt.Println("If N is given, run N iterations of the simulation (default 6).")
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 17,
+    Title: "Conway Cubes",
+    Parts: 2,
+    Options: []registry.Option{
+      {Name: "n", Default: 6, Usage: "run `N` iterations of the simulation",
+        Check: registry.NonNegative},
+    },
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"112", "848"}},
+    },
+    Main: Main,
+  })
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
+func Main(ctx context.Context, r *registry.Run) error {
+  iterations := r.Int("n")
 
-  iterations := 6
-  if len(args) > 0 {
-    if args[0] == "-h" || args[0] == "--help" {
-      Usage()
-      return nil
-    }
-    if args[0] == "-n" {
-      if len(args) < 2 {
-        return errors.New("-n requires an argument")
-      }
-      var err error
-      iterations, err = strconv.Atoi(args[1])
-      if err != nil {
-        return err
-      }
-    }
+  lines, err := util.ReadLines(r.Open())
+  if err != nil {
+    return err
   }
-
-  lines, err := util.ReadLinesFromFile(input_path)
+  plane, err := util.ParseGrid(lines, util.ByteSet(".#"))
   if err != nil {
     return err
   }
+  r.Parsed()
 
   // Part 1: Activate cells from the plane specified in the input.
-  dim := NewPocketDimension(3)
-  dim.ActivatePlane(lines)
-  fmt.Printf("There are initially %d active cells.\n", dim.ActiveCount())
-
-  if verbose {
-    fmt.Println(dim.ActiveStr())
-    fmt.Println("Extents:")
-    for dimNum, extents := range dim.GetExtents() {
-      fmt.Printf("  (dim%d) [min=%d, max=%d]\n", dimNum, extents[0], extents[1])
+  if r.Want(1) {
+    dim := NewPocketDimension(3, r.Log.Sub("PocketDimension"))
+    dim.ActivatePlane(plane)
+    initial := dim.ActiveCount()
+
+    if r.Log.Enabled(logging.Debug) {
+      r.Log.Debugf("%s", dim.ActiveStr())
+      r.Log.Debugf("Extents:")
+      for dimNum, extents := range dim.GetExtents() {
+        r.Log.Debugf("  (dim%d) [min=%d, max=%d]",
+          dimNum, extents[0], extents[1])
+      }
     }
-  }
 
-  // Simulate 6 times and count active cells.
-  dim.SimulateN(iterations)
-  fmt.Printf("After %d steps, there are %d active cells.\n",
-    iterations, dim.ActiveCount())
+    // Simulate 6 times and count active cells.
+    dim.SimulateN(iterations)
+    r.Answer(1, fmt.Sprintf("Active cells after %d steps", iterations),
+      dim.ActiveCount(),
+      fmt.Sprintf("There are initially %d active cells.", initial))
+  }
 
   // Part 2: Four dimensions!
-  dim4 := NewPocketDimension(4)
-  dim4.ActivatePlane(lines)
-  fmt.Printf("4 dimensions: There are initially %d active cells.\n",
-    dim4.ActiveCount())
-  dim4.SimulateN(iterations)
-  fmt.Printf("4 dimensions: After %d steps, there are %d active cells.\n",
-    iterations, dim4.ActiveCount())
+  if r.Want(2) {
+    dim4 := NewPocketDimension(4, r.Log.Sub("PocketDimension"))
+    dim4.ActivatePlane(plane)
+    initial := dim4.ActiveCount()
+    dim4.SimulateN(iterations)
+    r.Answer(2,
+      fmt.Sprintf("Active cells after %d steps in 4 dimensions", iterations),
+      dim4.ActiveCount(),
+      fmt.Sprintf("There are initially %d active cells.", initial))
+  }
 
 
   return nil
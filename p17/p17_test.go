@@ -0,0 +1,11 @@
+package p17
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 17) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 17) }
@@ -1,6 +1,175 @@
 package p18
 
-func Main(input_path string, verbose bool, args []string) error {
-  // TODO
+import (
+  "context"
+  _ "embed"
+  "fmt"
+  "github.com/fritzr/advent2020/registry"
+  "github.com/fritzr/advent2020/util"
+  "github.com/fritzr/advent2020/util/numtheory"
+)
+
+// Precedence of each operator: higher binds more tightly. Operators with
+// equal precedence are evaluated left to right.
+type Precedence map[byte]int
+
+var (
+  // Part 1: all operators are equal.
+  LeftToRight = Precedence{'+': 1, '*': 1}
+
+  // Part 2: addition before multiplication.
+  AdditionFirst = Precedence{'+': 2, '*': 1}
+)
+
+// Evaluates an expression by precedence climbing.
+type evaluator struct {
+  text string
+  pos int
+  prec Precedence
+}
+
+// Evaluate an expression of non-negative integers, '+', '*' and parentheses.
+// An invalid expression is reported as a util.ParseError, without a line.
+func Evaluate(expr string, prec Precedence) (int64, error) {
+  e := evaluator{expr, 0, prec}
+  value, err := e.expression(1)
+  if err != nil {
+    return 0, err
+  }
+  if e.peek() != 0 {
+    return 0, e.errorf("unexpected character")
+  }
+  return value, nil
+}
+
+// Skip spaces and return the next character, or 0 at the end.
+func (e *evaluator) peek() byte {
+  for e.pos < len(e.text) && e.text[e.pos] == ' ' {
+    e.pos++
+  }
+  if e.pos == len(e.text) {
+    return 0
+  }
+  return e.text[e.pos]
+}
+
+// An error at the current position.
+func (e *evaluator) errorf(format string, args ...interface{}) error {
+  var text string
+  if e.pos < len(e.text) {
+    text = e.text[e.pos:e.pos+1]
+  }
+  return util.ParseErrorf(0, e.pos + 1, text, format, args...)
+}
+
+// Evaluate operands joined by operators which bind at least as tightly as
+// minPrec.
+func (e *evaluator) expression(minPrec int) (int64, error) {
+  value, err := e.operand()
+  if err != nil {
+    return 0, err
+  }
+  for {
+    op := e.peek()
+    prec := e.prec[op]
+    if prec == 0 || prec < minPrec {
+      // Let the caller deal with ')' or anything unexpected.
+      return value, nil
+    }
+    opPos := e.pos
+    e.pos++
+    rhs, err := e.expression(prec + 1)
+    if err != nil {
+      return 0, err
+    }
+    switch op {
+    case '+':
+      value, err = numtheory.AddChecked(value, rhs)
+    case '*':
+      value, err = numtheory.MulChecked(value, rhs)
+    }
+    if err != nil {
+      return 0, util.NewParseError(0, opPos + 1, string(op), err)
+    }
+  }
+}
+
+// Evaluate a number or a parenthesized expression.
+func (e *evaluator) operand() (int64, error) {
+  c := e.peek()
+  if c == '(' {
+    e.pos++
+    value, err := e.expression(1)
+    if err != nil {
+      return 0, err
+    }
+    if e.peek() != ')' {
+      return 0, e.errorf("expected ')'")
+    }
+    e.pos++
+    return value, nil
+  }
+  if c < '0' || c > '9' {
+    return 0, e.errorf("expected a number or '('")
+  }
+  var value int64
+  for start := e.pos; e.pos < len(e.text); e.pos++ {
+    c = e.text[e.pos]
+    if c < '0' || c > '9' {
+      break
+    }
+    var err error
+    value, err = numtheory.MulChecked(value, 10)
+    if err == nil {
+      value, err = numtheory.AddChecked(value, int64(c - '0'))
+    }
+    if err != nil {
+      return 0, util.NewParseError(0, start + 1, e.text[start:e.pos+1], err)
+    }
+  }
+  return value, nil
+}
+
+//go:embed example1.txt
+var example1 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 18,
+    Title: "Operation Order",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Answers: []string{"26457", "694173"}},
+    },
+    Main: Main,
+  })
+}
+
+func Main(ctx context.Context, r *registry.Run) error {
+  lines, err := util.ReadLines(r.Open())
+  if err != nil {
+    return err
+  }
+  r.Parsed()
+
+  labels := []string{"Sum of the results", "Sum with addition first"}
+  for part, prec := range []Precedence{LeftToRight, AdditionFirst} {
+    if !r.Want(part + 1) {
+      continue
+    }
+    var sum int64
+    for index, line := range lines {
+      value, err := Evaluate(line, prec)
+      if err != nil {
+        return util.AtLine(err, index + 1)
+      }
+      if sum, err = numtheory.AddChecked(sum, value); err != nil {
+        return err
+      }
+    }
+    r.Answer(part + 1, labels[part], sum,
+      fmt.Sprintf("out of %d expressions", len(lines)))
+  }
+
   return nil
 }
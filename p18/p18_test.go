@@ -0,0 +1,44 @@
+package p18
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 18) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 18) }
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"",
+	"1 +",
+	"(1 + 2",
+	"1 + 2)",
+	"1 - 2",
+	"* 3",
+	"99999999999999999999",
+	"9999999999 * 9999999999",
+}
+
+func TestEvaluateMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := Evaluate(input, LeftToRight); err == nil {
+			t.Errorf("Evaluate(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzEvaluate(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	for _, line := range registrytest.InputLines(f, 18) {
+		f.Add(line)
+	}
+	f.Fuzz(func(t *testing.T, expr string) {
+		Evaluate(expr, LeftToRight)
+		Evaluate(expr, AdditionFirst)
+	})
+}
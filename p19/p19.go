@@ -1,15 +1,19 @@
 package p19
 
 import (
+  "context"
+  _ "embed"
   "fmt"
   "errors"
+  "io"
+  "sort"
   "strings"
   "strconv"
+  "github.com/fritzr/advent2020/logging"
+  "github.com/fritzr/advent2020/registry"
   "github.com/fritzr/advent2020/util"
 )
 
-var gVerbose = false
-
 type Rule interface { }
 
 // Match a literal string.
@@ -27,16 +31,48 @@ type Selector struct {
   any []Rule
 }
 
+func (l *Literal) String() string {
+  return fmt.Sprintf("%q", l.literal)
+}
+
+func (s *Sequence) String() string {
+  elements := make([]string, len(s.all))
+  for index, element := range s.all {
+    elements[index] = strconv.Itoa(element)
+  }
+  return strings.Join(elements, " ")
+}
+
+func (s *Selector) String() string {
+  rules := make([]string, len(s.any))
+  for index, rule := range s.any {
+    rules[index] = fmt.Sprint(rule)
+  }
+  return strings.Join(rules, " | ")
+}
+
 type Grammar struct {
   rules map[int]Rule
+
+  // Rules being matched, and where, to stop left recursion.
+  active map[[2]int]bool
+
+  // Each match is logged here at trace level, if not nil.
+  log *logging.Logger
 }
 
 func NewGrammar() *Grammar {
   g := new(Grammar)
   g.rules = make(map[int]Rule)
+  g.active = make(map[[2]int]bool)
   return g
 }
 
+// Log each message matched against the grammar to log at trace level.
+func (g *Grammar) SetLogger(log *logging.Logger) {
+  g.log = log
+}
+
 // We support two specific types of recursive rules:
 //   1. B -> A | AB
 //      This means "B matches A 1 or more times".
@@ -52,7 +88,7 @@ func NewGrammar() *Grammar {
 func (g *Grammar) literal(id int, rule *Literal, text string, index int) []int {
   // Match literal prefix.
   literal := rule.literal
-  if index < len(text) && text[index:index + len(literal)] == literal {
+  if strings.HasPrefix(text[index:], literal) {
     return []int{index + len(literal)}
   }
   return []int{}
@@ -90,6 +126,15 @@ func (g *Grammar) filter(id int, rule Rule, text string, suffixes []int,
 }
 
 func (g *Grammar) prefix(id int, rule Rule, text string, index int) []int {
+  // A rule which comes back to itself without consuming any text can never
+  // match.
+  key := [2]int{id, index}
+  if g.active[key] {
+    return []int{}
+  }
+  g.active[key] = true
+  defer delete(g.active, key)
+
   switch r := rule.(type) {
     case *Literal: return g.literal(id, r, text, index)
     case *Selector: return g.any(id, r, text, index)
@@ -99,28 +144,20 @@ func (g *Grammar) prefix(id int, rule Rule, text string, index int) []int {
 }
 
 func (g *Grammar) Accepts(text string) bool {
-  if gVerbose {
-    fmt.Printf("**** (len=%d) %s\n", len(text), text)
-  }
+  g.log.Tracef("**** (len=%d) %s", len(text), text)
   suffixes := g.prefix(0, g.rules[0], text, 0)
   if len(suffixes) > 0 {
     for _, suffix := range suffixes {
-      if gVerbose {
-        fmt.Printf("    text[:%d] matches (%s).\n", suffix, text[:suffix])
-      }
+      g.log.Tracef("    text[:%d] matches (%s).", suffix, text[:suffix])
       if suffix >= len(text) {
-        if gVerbose {
-          fmt.Println("=> Accepted.")
-        }
+        g.log.Tracef("=> Accepted.")
         return true
       }
     }
-  } else if gVerbose {
-    fmt.Println("    no matches.")
-  }
-  if gVerbose {
-    fmt.Println("=> Rejected.")
+  } else {
+    g.log.Tracef("    no matches.")
   }
+  g.log.Tracef("=> Rejected.")
   return false
 }
 
@@ -128,24 +165,92 @@ func (g *Grammar) SetRule(ruleId int, rule Rule) {
   g.rules[ruleId] = rule
 }
 
+// UndefinedRuleError reports a rule which refers to a rule that does not
+// exist.
+type UndefinedRuleError struct {
+  Rule int
+  Ref int
+}
+
+func (e *UndefinedRuleError) Error() string {
+  return fmt.Sprintf("rule %d refers to undefined rule %d", e.Rule, e.Ref)
+}
+
+// The rules referred to by a rule.
+func references(rule Rule) []int {
+  switch r := rule.(type) {
+    case *Sequence: return r.all
+    case *Selector:
+      refs := make([]int, 0)
+      for _, subRule := range r.any {
+        refs = append(refs, references(subRule)...)
+      }
+      return refs
+    default: return nil
+  }
+}
+
+// Validate checks that rule 0 exists and that every rule refers only to rules
+// which do, so that messages can be matched. An undefined reference is
+// reported as an *UndefinedRuleError.
+func (g *Grammar) Validate() error {
+  if _, ok := g.rules[0]; !ok {
+    return errors.New("rule 0 is not defined")
+  }
+  ids := make([]int, 0, len(g.rules))
+  for id := range g.rules {
+    ids = append(ids, id)
+  }
+  sort.Ints(ids)
+  for _, id := range ids {
+    for _, ref := range references(g.rules[id]) {
+      if _, ok := g.rules[ref]; !ok {
+        return &UndefinedRuleError{id, ref}
+      }
+    }
+  }
+  return nil
+}
+
+// Parse the rule numbers of a sequence, whose fields start at the given
+// offset into the rule.
+func parseSequence(fields []util.Field, start int) ([]int, error) {
+  elements := make([]int, len(fields))
+  for index, field := range fields {
+    element, err := strconv.Atoi(field.Text)
+    if err != nil {
+      return nil, util.NewParseError(0, start + field.Offset + 1, field.Text,
+        err)
+    }
+    elements[index] = element
+  }
+  return elements, nil
+}
+
+// Parse a rule like '0: 4 1 5', '1: 2 3 | 3 2' or '4: "a"'. An invalid rule
+// is reported as a util.ParseError, without a line.
 func (g *Grammar) ParseRule(rule string) error {
-  parts := strings.Split(rule, ": ")
+  const separator = ": "
+  parts := strings.Split(rule, separator)
   if len(parts) != 2 {
-    return errors.New("expected 'index: rule'")
+    return util.NewParseError(0, 1, rule,
+      errors.New("expected 'index: rule'"))
   }
 
   index, err := strconv.Atoi(parts[0])
   if err != nil {
-    return err
+    return util.NewParseError(0, 1, parts[0], err)
   }
 
-  fields := strings.Fields(parts[1])
+  bodyStart := len(parts[0]) + len(separator)
+  fields := util.FieldsAt(parts[1])
   if len(fields) == 0 {
-    return errors.New("empty rule body")
+    return util.NewParseError(0, bodyStart + 1, "",
+      errors.New("empty rule body"))
   }
 
   // Literal rule.
-  firstWord := fields[0]
+  firstWord := fields[0].Text
   if len(fields) == 1 && len(firstWord) > 2 && (
       firstWord[0] == '"' && firstWord[len(firstWord)-1] == '"') {
     g.SetRule(index, &Literal{firstWord[1:len(firstWord)-1]})
@@ -156,12 +261,12 @@ func (g *Grammar) ParseRule(rule string) error {
   startIndex := 0
   rules := make([]Rule, 0)
   for fieldIndex := 0; fieldIndex < len(fields); fieldIndex++ {
-    if fields[fieldIndex] == "|" {
+    if fields[fieldIndex].Text == "|" {
       if fieldIndex == 0 || startIndex == fieldIndex {
-        return errors.New(fmt.Sprintf(
-          "rule parse error: unexpected '|' in '%s'", parts[1]))
+        return util.NewParseError(0, bodyStart + fields[fieldIndex].Offset + 1,
+          "|", errors.New("unexpected '|' in rule"))
       }
-      elements, err2 := util.FieldsToInts(fields[startIndex:fieldIndex])
+      elements, err2 := parseSequence(fields[startIndex:fieldIndex], bodyStart)
       if err2 != nil {
         return err2
       }
@@ -169,7 +274,7 @@ func (g *Grammar) ParseRule(rule string) error {
       startIndex = fieldIndex + 1
     }
   }
-  finalElements, err2 := util.FieldsToInts(fields[startIndex:])
+  finalElements, err2 := parseSequence(fields[startIndex:], bodyStart)
   if err2 != nil {
     return err2
   }
@@ -183,56 +288,192 @@ func (g *Grammar) ParseRule(rule string) error {
   return nil
 }
 
+// Parse one rule per line, and Validate them. An undefined reference is
+// reported at the line of the rule which makes it.
 func (g *Grammar) ParseRules(rulesText string) error {
   lines := strings.Split(rulesText, "\n")
-  for _, line := range lines {
+  ruleLines := make(map[int]int, len(lines))
+  for lineNumber, line := range lines {
     if err := g.ParseRule(line); err != nil {
-      return err
+      return util.AtLine(err, lineNumber + 1)
     }
+    id, _ := strconv.Atoi(line[:strings.Index(line, ":")])
+    ruleLines[id] = lineNumber + 1
   }
-  return nil
+  err := g.Validate()
+  var undefined *UndefinedRuleError
+  if errors.As(err, &undefined) {
+    return util.AtLine(err, ruleLines[undefined.Rule])
+  }
+  return err
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-  gVerbose = verbose
-  groups, err := util.ReadLineGroupsFromFile(input_path)
-  if err != nil {
-    return err
-  }
+//go:embed example1.txt
+var example1 string
+
+//go:embed example2.txt
+var example2 string
+
+func init() {
+  registry.Register(registry.Puzzle{
+    Day: 19,
+    Title: "Monster Messages",
+    Parts: 2,
+    Examples: []registry.Example{
+      {Input: example1, Part: 1, Answers: []string{"2"}},
+      {Input: example2, Answers: []string{"3", "12"}},
+    },
+    Main: Main,
+    Repl: Repl,
+  })
+}
 
-  if len(groups) != 2 {
-    return errors.New("expected 2 groups in input")
+// Read the rules into g, followed by the messages, if any. The input is
+// streamed so only one group of lines is held at a time.
+func readInput(input io.Reader, g *Grammar) (messages []string, err error) {
+  groups := util.NewLineGroupScanner(input)
+  for groups.Scan() {
+    switch groups.Count() {
+    case 1:
+      if err = g.ParseRules(groups.Text()); err != nil {
+        return nil, util.AtLine(err, groups.Line())
+      }
+    case 2:
+      messages = strings.Split(groups.Text(), "\n")
+    }
+  }
+  if err = groups.Err(); err != nil {
+    return nil, err
+  }
+  if groups.Count() == 0 || groups.Count() > 2 {
+    return nil, fmt.Errorf("expected 1 or 2 groups of lines, found %d",
+      groups.Count())
   }
+  return messages, nil
+}
 
+func Main(ctx context.Context, r *registry.Run) error {
   g := NewGrammar()
-  err = g.ParseRules(groups[0])
+  g.SetLogger(r.Log.Sub("Grammar"))
+  messages, err := readInput(r.Open(), g)
   if err != nil {
     return err
   }
+  if messages == nil {
+    return errors.New("expected 2 groups of lines, found 1")
+  }
 
   // Part 1: see how many messages are accepted.
-  messages := strings.Split(groups[1], "\n")
-  valid := 0
-  for _, message := range messages {
-    if g.Accepts(message) {
-      valid++
+  r.Parsed()
+  if r.Want(1) {
+    valid := 0
+    for _, message := range messages {
+      if g.Accepts(message) {
+        valid++
+      }
     }
+    r.Answer(1, "Valid messages", valid,
+      fmt.Sprintf("out of %d messages", len(messages)))
   }
-  fmt.Printf("%d / %d messages are valid.\n", valid, len(messages))
 
   // Part 2: replace 8 and 11 with some recursive rules.
-  valid = 0
+  if !r.Want(2) {
+    return nil
+  }
+  valid := 0
   g.SetRule(8, &Selector{[]Rule{
     &Sequence{[]int{42}}, &Sequence{[]int{42, 8}}}})
   g.SetRule(11, &Selector{[]Rule{
     &Sequence{[]int{42, 31}}, &Sequence{[]int{42, 11, 31}}}})
+  if err = g.Validate(); err != nil {
+    return fmt.Errorf("part 2: %w", err)
+  }
   for _, message := range messages {
     if g.Accepts(message) {
       valid++
     }
   }
-  fmt.Printf("%d / %d messages are valid with recursive rules.\n",
-    valid, len(messages))
+  r.Answer(2, "Valid messages with recursive rules", valid,
+    fmt.Sprintf("out of %d messages", len(messages)))
 
   return nil
 }
+
+func Repl(ctx context.Context, r *registry.Run) ([]registry.Command, error) {
+  g := NewGrammar()
+  g.SetLogger(r.Log.Sub("Grammar"))
+  messages, err := readInput(r.Open(), g)
+  if err != nil {
+    return nil, err
+  }
+
+  return []registry.Command{
+    {Name: "accepts", Args: "MESSAGE", Usage: "check whether rule 0 " +
+      "matches MESSAGE",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        if len(args) != 1 {
+          return registry.ErrUsage
+        }
+        if g.Accepts(args[0]) {
+          fmt.Fprintln(w, "accepted")
+        } else {
+          fmt.Fprintln(w, "rejected")
+        }
+        return nil
+      }},
+    {Name: "rule", Args: "ID [RULE]", Usage: "show rule ID, or replace it " +
+      "with RULE, like '8 42 | 42 8'",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        if len(args) == 0 {
+          return registry.ErrUsage
+        }
+        id, err := strconv.Atoi(args[0])
+        if err != nil {
+          return registry.ErrUsage
+        }
+        if len(args) > 1 {
+          old, existed := g.rules[id]
+          err = g.ParseRule(fmt.Sprintf("%d: %s", id,
+            strings.Join(args[1:], " ")))
+          // The position is in a rule made up from the arguments.
+          var parseErr *util.ParseError
+          if errors.As(err, &parseErr) {
+            return fmt.Errorf("%v: %q", parseErr.Err, parseErr.Text)
+          }
+          if err == nil {
+            err = g.Validate()
+          }
+          // Keep the grammar usable: put back the rule this one replaced.
+          if err != nil {
+            if existed {
+              g.SetRule(id, old)
+            } else {
+              delete(g.rules, id)
+            }
+          }
+          return err
+        }
+        rule, ok := g.rules[id]
+        if !ok {
+          return fmt.Errorf("no rule %d", id)
+        }
+        fmt.Fprintf(w, "%d: %v\n", id, rule)
+        return nil
+      }},
+    {Name: "messages", Usage: "count the messages from the input which rule " +
+      "0 matches",
+      Run: func(ctx context.Context, w io.Writer, args []string) error {
+        valid := 0
+        for _, message := range messages {
+          if err := ctx.Err(); err != nil {
+            return err
+          }
+          if g.Accepts(message) {
+            valid++
+          }
+        }
+        fmt.Fprintf(w, "%d of %d messages accepted\n", valid, len(messages))
+        return nil
+      }},
+  }, nil
+}
@@ -0,0 +1,98 @@
+package p19
+
+import (
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 19) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 19) }
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"",
+	"0",
+	"0: 1 x",
+	"x: 1 2",
+	"0: \"a",
+}
+
+func TestParseRuleMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if err := NewGrammar().ParseRule(input); err == nil {
+			t.Errorf("ParseRule(%q) succeeded", input)
+		}
+	}
+}
+
+// Sets of rules which parse, but cannot be used to match messages.
+var malformedRules = []string{
+	"0: 1 2\n1: \"a\"",
+	"1: \"a\"",
+	"0: 1 | 2\n1: \"a\"",
+}
+
+func TestParseRulesMalformed(t *testing.T) {
+	for _, input := range malformedRules {
+		if err := NewGrammar().ParseRules(input); err == nil {
+			t.Errorf("ParseRules(%q) succeeded", input)
+		}
+	}
+}
+
+func TestAccepts(t *testing.T) {
+	g := NewGrammar()
+	if err := g.ParseRules("0: 1 2 | 1 0\n1: \"ab\"\n2: \"b\""); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		message string
+		want    bool
+	}{
+		// A literal longer than the rest of the message does not match.
+		{"a", false},
+		{"ab", false},
+		{"abb", true},
+		{"ababb", true},
+		{"abab", false},
+	}
+	for _, test := range tests {
+		if got := g.Accepts(test.message); got != test.want {
+			t.Errorf("Accepts(%q) = %v, want %v", test.message, got, test.want)
+		}
+	}
+}
+
+func TestAcceptsLeftRecursion(t *testing.T) {
+	// Rules which refer back to themselves without consuming any text only
+	// match through their other alternatives, rather than recursing forever.
+	g := NewGrammar()
+	if err := g.ParseRules("0: 0 1 | 1\n1: \"a\""); err != nil {
+		t.Fatal(err)
+	}
+	if !g.Accepts("a") || g.Accepts("aa") {
+		t.Error("left recursion matched more than its base case")
+	}
+}
+
+func FuzzParseRule(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input, "ab")
+	}
+	for _, input := range malformedRules {
+		f.Add(input, "ab")
+	}
+	for _, line := range registrytest.InputLines(f, 19) {
+		f.Add(line, "a")
+	}
+	f.Add("0: 1\n1: \"ab\"", "a")
+	f.Add("0: 1 2 | 1 0\n1: \"ab\"\n2: \"b\"", "ababb")
+	f.Fuzz(func(t *testing.T, rules string, message string) {
+		g := NewGrammar()
+		if g.ParseRules(rules) == nil {
+			g.Accepts(message)
+		}
+	})
+}
@@ -1,18 +1,19 @@
 package p20
 
 import (
+	"context"
+	_ "embed"
 	"errors"
 	"fmt"
+	"github.com/fritzr/advent2020/logging"
+	"github.com/fritzr/advent2020/registry"
 	"github.com/fritzr/advent2020/util"
-	"gonum.org/v1/gonum/mat" // yeah it might be overkill, but I want to learn
 	"math"
 	"sort"
 	"strconv"
 	"strings"
 )
 
-var gVerbose = false
-
 // Directions.
 const (
 	RIGHT = iota
@@ -38,65 +39,36 @@ var flipString = [...]string{"flipped", "unflipped"}
 
 const NUM_FLIPS = 1 + FLIPPED
 
-// Some random prime numbers.
-// Specifically, 10 of them, since our input consists of 10x10 tiles.
-// If you want to support larger input, use a larger set of primes.
-var primes = [...]int{
-	2267, 63103, 30713, 8861, 43441, 1753, 5023, 14173, 46601, 35491}
-
-// *Very* simple hash routine.
-func floatHash(values mat.Vector) (hash float64) {
-	for index := 0; index < values.Len(); index++ {
-		hash += float64(primes[index%len(primes)]) * values.AtVec(index)
-	}
-	return
+// The edge on top of each of a tile's orientations, in the order of
+// util.Grid.Orientations. Rotating a tile clockwise brings its left edge to
+// the top, read from the bottom up.
+var orientationEdges = [...][2]int{
+	{UP, UNFLIPPED}, {UP, FLIPPED},
+	{LEFT, FLIPPED}, {LEFT, UNFLIPPED},
+	{DOWN, FLIPPED}, {DOWN, UNFLIPPED},
+	{RIGHT, UNFLIPPED}, {RIGHT, FLIPPED},
 }
 
-type edgeHashList = [NUM_DIRECTIONS][NUM_FLIPS]float64
+type edgeList = [NUM_DIRECTIONS][NUM_FLIPS]string
 
 type Tile struct {
-	*mat.Dense
-	// Hash of each edge.
+	*util.Grid[byte]
+	// The cells along each edge.
 	//
-	// `edge[d][f]` is the hash of the edge in the given direction d
-	// after applying the flip f. Some examples:
+	// `edge[d][f]` is the edge in the given direction d after applying the
+	// flip f, read left to right or top to bottom. Some examples:
 	//
 	//   right := edge[RIGHT][UNFLIPPED]
 	//   left := edge[LEFT][UNFLIPPED]
 	//   upFlipped := edge[UP][FLIPPED]
 	//
 	id    int
-	edges edgeHashList
+	edges edgeList
 }
 
-type ReverseViewer interface {
-	ReverseRowView(row int) mat.Vector
-	ReverseColView(row int) mat.Vector
-}
-
-type reverseVector struct {
-	mat.Vector
-}
-
-func (r *reverseVector) AtVec(index int) float64 {
-	return r.Vector.AtVec(r.Len() - index - 1)
-}
-
-type denseReverseViewable struct {
-	*mat.Dense
-}
-
-func (t *denseReverseViewable) ReverseRowView(row int) mat.Vector {
-	return &reverseVector{t.RowView(row)}
-}
-
-func (t *denseReverseViewable) ReverseColView(row int) mat.Vector {
-	return &reverseVector{t.ColView(row)}
-}
-
-func NewTile(id int, nrows int, ncols int, data []float64) *Tile {
-	// First extract hashes of the borders.
-	// The matrix is oriented with directional edges as follows:
+func NewTile(id int, grid *util.Grid[byte]) *Tile {
+	// First extract the borders.
+	// The grid is oriented with directional edges as follows:
 	//
 	//        0  ...  NC-1
 	//      +-------------
@@ -104,61 +76,61 @@ func NewTile(id int, nrows int, ncols int, data []float64) *Tile {
 	// ...  | L  ...  R
 	// NR-1 | LD  D   RD
 	//
-	// Compute the hashes of each edge now.
 	// An arrangement of tile T1 is adjacent to an arrangement of another tile T2
 	// when T1.edges[d1][f1] = T2.edges[d2][f2] for some e1,e2,f1,f2.
-	// By pre-computing all edge hashes we can query this quickly (assuming our
-	// hashes have no collisions for the input).
-	full := denseReverseViewable{mat.NewDense(nrows, ncols, data)}
-	edges := edgeHashList{}
-	edges[UP][UNFLIPPED] = floatHash(full.RowView(0))
-	edges[UP][FLIPPED] = floatHash(full.ReverseRowView(0))
-	edges[DOWN][UNFLIPPED] = floatHash(full.RowView(nrows - 1))
-	edges[DOWN][FLIPPED] = floatHash(full.ReverseRowView(nrows - 1))
-	edges[LEFT][UNFLIPPED] = floatHash(full.ColView(0))
-	edges[LEFT][FLIPPED] = floatHash(full.ReverseColView(0))
-	edges[RIGHT][UNFLIPPED] = floatHash(full.ColView(ncols - 1))
-	edges[RIGHT][FLIPPED] = floatHash(full.ReverseColView(ncols - 1))
-
-	// Only store the core data -- omit the borders, as the hashes are all that
-	// is necessary (assuming no hash collisions).
-	return &Tile{full.Slice(1, nrows-1, 1, ncols-1).(*mat.Dense), id, edges}
+	// Each orientation of the tile has a different one of its edges on top.
+	edges := edgeList{}
+	for index, orientation := range grid.Orientations() {
+		edge := orientationEdges[index]
+		edges[edge[0]][edge[1]] = string(orientation.Row(0))
+	}
+
+	// Only store the core data -- omit the borders, as the edges are all that
+	// is necessary.
+	return &Tile{grid.Slice(1, 1, grid.Rows()-1, grid.Cols()-1), id, edges}
 }
 
-func BytesToFloat(data []byte) []float64 {
-	float := make([]float64, len(data))
-	for index, c := range data {
-		float[index] = float64(c)
+// parseTile creates a tile from its string representation: a "Tile <id>:"
+// header and the line-delimited rows of a square matrix. An invalid tile is
+// reported as a util.ParseError relative to the header.
+func parseTile(tileStr string) (*Tile, error) {
+	lines := strings.Split(tileStr, "\n")
+	header := lines[0]
+	if !strings.HasPrefix(header, "Tile ") || !strings.HasSuffix(header, ":") {
+		return nil, util.NewParseError(1, 1, header,
+			errors.New("expected 'Tile <id>:' header"))
+	}
+	idText := header[len("Tile ") : len(header)-1]
+	id, err := strconv.Atoi(idText)
+	if err != nil {
+		return nil, util.NewParseError(1, len("Tile ")+1, idText, err)
+	}
+	grid, err := util.ParseGrid(lines[1:], util.ByteSet(".#"))
+	if err != nil {
+		return nil, util.AtLine(err, 2)
+	}
+	// Tiles need an interior besides their edges.
+	if grid.Cols() < 3 {
+		return nil, util.ParseErrorf(1, 1, header, "tile %d is too small", id)
 	}
-	return float
+	if grid.Rows() != grid.Cols() {
+		return nil, util.ParseErrorf(1, 1, header,
+			"tile has invalid dimensions %d x %d", grid.Rows(), grid.Cols())
+	}
+	return NewTile(id, grid), nil
 }
 
-// parseTiles creates tiles from string representations.
-//
-// Each tile string describes a square matrix by line-delimited rows.
-func parseTiles(tileStrings []string) (tiles map[int]*Tile, err error) {
-	tiles = make(map[int]*Tile, len(tileStrings))
-	for _, tileStr := range tileStrings {
-		// First line is "Tile <id>:\n"
-		idStart := strings.IndexRune(tileStr, ' ') + 1
-		firstLine := strings.IndexRune(tileStr, '\n')
-		var id int
-		id, err = strconv.Atoi(tileStr[idStart : firstLine-1])
+// parseTiles creates tiles from groups of lines, each of which is a tile.
+func parseTiles(groups *util.LineGroupScanner) (map[int]*Tile, error) {
+	tiles := make(map[int]*Tile)
+	for groups.Scan() {
+		tile, err := parseTile(groups.Text())
 		if err != nil {
-			break
+			return tiles, util.AtLine(err, groups.Line())
 		}
-		ncols := strings.IndexRune(tileStr[firstLine+1:], '\n')
-		tileData := strings.Replace(tileStr[firstLine+1:], "\n", "", -1)
-		nrows := len(tileData) / ncols
-		if ncols != nrows || len(tileData) != nrows*ncols {
-			err = errors.New(fmt.Sprintf(
-				"tile has invalid dimensions %d x %d (%d elements)",
-				nrows, ncols, len(tileData)))
-			break
-		}
-		tiles[id] = NewTile(id, nrows, ncols, BytesToFloat([]byte(tileData)))
+		tiles[tile.id] = tile
 	}
-	return
+	return tiles, groups.Err()
 }
 
 type TileEdge struct {
@@ -212,7 +184,8 @@ type AdjacencyMap = map[int]AdjacencyList
 //   .#.|#  #|#.#
 //   ..#|.  .|.#.
 //
-func Adjacencies(tiles map[int]*Tile) AdjacencyMap {
+// Each adjacency found is logged to log at trace level; log may be nil.
+func Adjacencies(tiles map[int]*Tile, log *logging.Logger) AdjacencyMap {
 	// Map tile IDs to their adjacent tiles (by ID).
 	// The indexes of the value are the constants RIGHT, LEFT, UP, DOWN, etc...
 	// A zero value (we assume no tile has ID zero) means unknown adjancency.
@@ -232,8 +205,8 @@ func Adjacencies(tiles map[int]*Tile) AdjacencyMap {
 		ids[index] = id
 		index++
 	}
-	// Sort the list of IDs for repeatable runs in verbose mode.
-	if gVerbose {
+	// Sort the list of IDs for repeatable runs when tracing.
+	if log.Enabled(logging.Trace) {
 		sort.Ints(ids)
 	}
 
@@ -250,9 +223,7 @@ func Adjacencies(tiles map[int]*Tile) AdjacencyMap {
 							e1, e2 := TileEdge{id1, d1, f1}, TileEdge{id2, d2, f2}
 							if IsAdjacent(t1, &e1, t2, &e2) {
 								adjacency := Adjacency{e1, e2}
-								if gVerbose {
-									fmt.Printf("%v adjacent to %v\n", e1, e2)
-								}
+								log.Tracef("%v adjacent to %v", e1, e2)
 								if _, ok := adjacent[id1]; !ok {
 									adjacent[id1] = make(AdjacencyList, 0, len(tiles)-1)
 								}
@@ -261,9 +232,9 @@ func Adjacencies(tiles map[int]*Tile) AdjacencyMap {
 									adjacent[id2] = make(AdjacencyList, 0, len(tiles)-1)
 								}
 								adjacent[id2] = append(adjacent[id2], adjacency)
-							} /* else if gVerbose {
+							} /* else {
 								e1, e2 := TileEdge{id1, d1, f1}, TileEdge{id2, d2, f2}
-								fmt.Printf("%v not adjacent to %v\n", e1, e2)
+								log.Tracef("%v not adjacent to %v", e1, e2)
 							} */
 						}
 					}
@@ -275,17 +246,16 @@ func Adjacencies(tiles map[int]*Tile) AdjacencyMap {
 	return adjacent
 }
 
-func Corners(tiles map[int]*Tile) (corners [4]int, err error) {
+func Corners(tiles map[int]*Tile, log *logging.Logger) (corners [4]int,
+	err error) {
 	// There should be only four tiles with exactly two adjacent tiles.
 	// XXX Currently we count each adjacency twice, so look for four adjacents.
-	adjacencies := Adjacencies(tiles)
+	adjacencies := Adjacencies(tiles, log)
 
 	cornerNum := 0
 	for id, adjacency := range adjacencies {
 		if 2 == len(adjacency)/2 /* XXX fix div 2 */ {
-			if gVerbose {
-				fmt.Printf("%d has 2 adjacencies: %v\n", id, adjacency)
-			}
+			log.Debugf("%d has 2 adjacencies: %v", id, adjacency)
 			if cornerNum == 4 {
 				err = errors.New("too many corners!")
 				break
@@ -302,18 +272,28 @@ func Corners(tiles map[int]*Tile) (corners [4]int, err error) {
 	return
 }
 
-func Main(input_path string, verbose bool, args []string) error {
-	gVerbose = verbose
-	tileStrings, err := util.ReadLineGroupsFromFile(input_path)
-	if err != nil || len(tileStrings) == 0 {
-		return err
-	}
+//go:embed example1.txt
+var example1 string
+
+func init() {
+	registry.Register(registry.Puzzle{
+		Day:   20,
+		Title: "Jurassic Jigsaw",
+		Parts: 1,
+		Examples: []registry.Example{
+			{Input: example1, Answers: []string{"20899048083289"}},
+		},
+		Main: Main,
+	})
+}
 
+func Main(ctx context.Context, r *registry.Run) error {
 	// Convert tiles from string to matrix representation.
-	tiles, err := parseTiles(tileStrings)
-	if err != nil {
+	tiles, err := parseTiles(util.NewLineGroupScanner(r.Open()))
+	if err != nil || len(tiles) == 0 {
 		return err
 	}
+	r.Parsed()
 
 	// Find the square dimensions of the output.
 	fSqrt := math.Sqrt(float64(len(tiles)))
@@ -324,9 +304,7 @@ func Main(input_path string, verbose bool, args []string) error {
 
 	var nrows, ncols int
 	for _, tile := range tiles {
-		nrows, ncols = tile.Dims()
-		fmt.Printf("Read %dx%d=%d tiles sized %dx%d.\n",
-			dim, dim, len(tiles), nrows, ncols)
+		nrows, ncols = tile.Rows(), tile.Cols()
 		break
 	}
 
@@ -354,21 +332,25 @@ func Main(input_path string, verbose bool, args []string) error {
 			grid[nrows*ncols-1]}
 	*/
 
-	corners, err := Corners(tiles)
+	corners, err := Corners(tiles, r.Log)
 	if err != nil {
 		return err
 	}
 
-	// Print the product of the corner IDs.
+	// Report the product of the corner IDs.
+	var product strings.Builder
 	result := 1
 	for index, value := range corners {
 		if index != 0 {
-			fmt.Printf(" * ")
+			product.WriteString(" * ")
 		}
-		fmt.Printf("%d", value)
+		product.WriteString(strconv.Itoa(value))
 		result *= value
 	}
-	fmt.Printf(" = %d\n", result)
+	r.Answer(1, "Product of the corner tile IDs", result,
+		fmt.Sprintf("Read %dx%d=%d tiles sized %dx%d.",
+			dim, dim, len(tiles), nrows, ncols),
+		product.String())
 
 	return nil
 }
@@ -0,0 +1,50 @@
+package p20
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/fritzr/advent2020/registry/registrytest"
+	"github.com/fritzr/advent2020/util"
+)
+
+func TestExamples(t *testing.T) { registrytest.Examples(t, 20) }
+
+func TestInput(t *testing.T) { registrytest.Input(t, 20) }
+
+// Inputs the parser must reject.
+var malformed = []string{
+	"#.\n.#",
+	"Tile x:\n#.\n.#",
+	"Tile 1:\n#.\n.",
+	"Tile 1:\n#.#\n.#.",
+	"Tile 1:\n#x\n.#",
+}
+
+func TestParseTileMalformed(t *testing.T) {
+	for _, input := range malformed {
+		if _, err := parseTile(input); err == nil {
+			t.Errorf("parseTile(%q) succeeded", input)
+		}
+	}
+}
+
+func FuzzParseTiles(f *testing.F) {
+	for _, input := range malformed {
+		f.Add(input)
+	}
+	groups, err := util.ReadLineGroups(
+		strings.NewReader(string(registrytest.InputData(f, 20))))
+	if err != nil {
+		f.Fatal(err)
+	}
+	if len(groups) > 5 {
+		groups = groups[:5]
+	}
+	for _, group := range groups {
+		f.Add(group)
+	}
+	f.Fuzz(func(t *testing.T, tile string) {
+		parseTile(tile)
+	})
+}
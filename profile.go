@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+var cpuProfile string
+var memProfile string
+var tracePath string
+
+// Files being written by the active profiles.
+var cpuFile, traceFile *os.File
+
+// Start the profiles requested on the command line.
+func startProfiling() error {
+	if cpuProfile != "" {
+		file, err := os.Create(cpuProfile)
+		if err != nil {
+			return err
+		}
+		if err = pprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return fmt.Errorf("cpu profile: %v", err)
+		}
+		cpuFile = file
+	}
+	if tracePath != "" {
+		file, err := os.Create(tracePath)
+		if err != nil {
+			return err
+		}
+		if err = trace.Start(file); err != nil {
+			file.Close()
+			return fmt.Errorf("trace: %v", err)
+		}
+		traceFile = file
+	}
+	return nil
+}
+
+// Stop the active profiles and write the memory profile, if requested.
+//
+// Errors are logged rather than returned, since this runs on the way out.
+func stopProfiling() {
+	if cpuFile != nil {
+		pprof.StopCPUProfile()
+		if err := cpuFile.Close(); err != nil {
+			log.Print(err)
+		}
+		cpuFile = nil
+	}
+	if traceFile != nil {
+		trace.Stop()
+		if err := traceFile.Close(); err != nil {
+			log.Print(err)
+		}
+		traceFile = nil
+	}
+	if memProfile != "" {
+		if err := writeMemProfile(memProfile); err != nil {
+			log.Print(err)
+		}
+		memProfile = ""
+	}
+}
+
+func writeMemProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	// Collect garbage first so the profile reflects live memory accurately.
+	runtime.GC()
+	if err = pprof.WriteHeapProfile(file); err != nil {
+		file.Close()
+		return fmt.Errorf("memory profile: %v", err)
+	}
+	return file.Close()
+}
+
+// Exit with the given status, stopping any profiles first.
+func exit(code int) {
+	stopProfiling()
+	os.Exit(code)
+}
+
+// Like log.Fatal, but stop any profiles first.
+func fatal(v ...interface{}) {
+	log.Print(v...)
+	exit(1)
+}
+
+// Like log.Fatalf, but stop any profiles first.
+func fatalf(format string, v ...interface{}) {
+	log.Printf(format, v...)
+	exit(1)
+}
@@ -0,0 +1,178 @@
+package registry
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// AnswerKey identifies a part of a day's puzzle.
+type AnswerKey struct {
+	Day  int
+	Part int
+}
+
+// Answers maps puzzle parts to their expected answers.
+//
+// The text form has one answer per line, "DAY PART ANSWER", separated by
+// whitespace. Blank lines and lines starting with '#' are ignored.
+type Answers map[AnswerKey]string
+
+// ReadAnswers parses answers from their text form.
+func ReadAnswers(input io.Reader) (Answers, error) {
+	answers := make(Answers)
+	scanner := bufio.NewScanner(input)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) != 3 {
+			return answers, fmt.Errorf(
+				"line %d: expected 'DAY PART ANSWER', got '%s'", lineNumber, line)
+		}
+		day, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return answers, fmt.Errorf("line %d: invalid day: %v", lineNumber, err)
+		}
+		part, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return answers, fmt.Errorf("line %d: invalid part: %v", lineNumber, err)
+		}
+		answers[AnswerKey{day, part}] = fields[2]
+	}
+	return answers, scanner.Err()
+}
+
+// ReadAnswersFromFile reads answers from a file.
+//
+// A file which does not exist holds no answers.
+func ReadAnswersFromFile(path string) (Answers, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return make(Answers), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return ReadAnswers(file)
+}
+
+// Write the answers in text form ordered by day and part.
+func (a Answers) Write(output io.Writer) error {
+	keys := make([]AnswerKey, 0, len(a))
+	for key := range a {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Day != keys[j].Day {
+			return keys[i].Day < keys[j].Day
+		}
+		return keys[i].Part < keys[j].Part
+	})
+	w := bufio.NewWriter(output)
+	fmt.Fprintln(w, "# DAY PART ANSWER")
+	for _, key := range keys {
+		fmt.Fprintf(w, "%d %d %s\n", key.Day, key.Part, a[key])
+	}
+	return w.Flush()
+}
+
+// WriteFile writes the answers to a file, replacing its contents.
+func (a Answers) WriteFile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err = a.Write(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// Record the answers to each part of the puzzle reported by a run.
+func (a Answers) Record(r *Run) {
+	for _, result := range r.Results {
+		if result.Part > 0 {
+			a[AnswerKey{r.Puzzle.Day, result.Part}] = result.Answer()
+		}
+	}
+}
+
+// Verdict is the outcome of checking an answer.
+type Verdict int
+
+const (
+	Pass Verdict = iota
+	Fail
+	// No answer is expected for the part.
+	Missing
+)
+
+var verdictString = [...]string{"PASS", "FAIL", "MISSING"}
+
+func (v Verdict) String() string {
+	if v < 0 || int(v) >= len(verdictString) {
+		return fmt.Sprintf("Verdict(%d)", int(v))
+	}
+	return verdictString[v]
+}
+
+// Check is the verdict for a single part of a run.
+type Check struct {
+	Part     int
+	Verdict  Verdict
+	Answer   string // empty if the run did not answer the part
+	Expected string // empty if no answer is expected
+}
+
+func (c Check) String() string {
+	answer := c.Answer
+	if answer == "" {
+		answer = "(no answer)"
+	}
+	if c.Verdict == Fail && c.Expected != "" {
+		return fmt.Sprintf("%s part %d: %s (expected %s)",
+			c.Verdict, c.Part, answer, c.Expected)
+	}
+	return fmt.Sprintf("%s part %d: %s", c.Verdict, c.Part, answer)
+}
+
+// Check the answers reported by a run against the expected answers.
+//
+// Every wanted part the puzzle solves is checked, whether or not it was
+// answered.
+func (a Answers) Check(r *Run) []Check {
+	checks := make([]Check, 0, r.Puzzle.Parts)
+	for part := 1; part <= r.Puzzle.Parts; part++ {
+		if !r.Want(part) {
+			continue
+		}
+		check := Check{Part: part}
+		if result := r.Result(part); result != nil {
+			check.Answer = result.Answer()
+		}
+		expected, ok := a[AnswerKey{r.Puzzle.Day, part}]
+		switch {
+		case !ok:
+			check.Verdict = Missing
+		case expected == check.Answer:
+			check.Verdict = Pass
+			check.Expected = expected
+		default:
+			check.Verdict = Fail
+			check.Expected = expected
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
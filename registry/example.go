@@ -0,0 +1,80 @@
+package registry
+
+import "fmt"
+
+// Example is a sample input from a puzzle's description, with the answers the
+// description gives for it.
+//
+// Days usually embed the example inputs from files next to their source, so
+// examples can run without touching the filesystem.
+type Example struct {
+	// The example input itself.
+	Input string
+
+	// Puzzle-specific arguments the example needs, if any.
+	Args []string
+
+	// The only part the example applies to, or 0 if it applies to every part.
+	// Some examples are only meaningful (or only feasible) for one part.
+	Part int
+
+	// Expected answer to each part, starting from part 1.
+	// An empty string means the answer to that part is not known.
+	Answers []string
+}
+
+// Expected returns the expected answer to a part, or "" if it is not known.
+func (e *Example) Expected(part int) string {
+	if part < 1 || part > len(e.Answers) {
+		return ""
+	}
+	return e.Answers[part-1]
+}
+
+// Example returns the puzzle's n-th example, counting from 1.
+func (p *Puzzle) Example(n int) (*Example, error) {
+	if n < 1 || n > len(p.Examples) {
+		if len(p.Examples) == 0 {
+			return nil, fmt.Errorf("day %d has no examples", p.Day)
+		}
+		return nil, fmt.Errorf("day %d has no example %d (it has %d)",
+			p.Day, n, len(p.Examples))
+	}
+	return &p.Examples[n-1], nil
+}
+
+// NewExampleRun prepares to run the puzzle on its n-th example.
+//
+// The run's arguments and part are taken from the example.
+func (p *Puzzle) NewExampleRun(n int) (*Run, error) {
+	example, err := p.Example(n)
+	if err != nil {
+		return nil, err
+	}
+	r := p.NewRun(fmt.Sprintf("example %d", n), []byte(example.Input))
+	r.Args = example.Args
+	r.Part = example.Part
+	return r, nil
+}
+
+// Check the answers reported by a run against those given for the example.
+//
+// Only the parts with known answers which the run wanted are checked.
+func (e *Example) Check(r *Run) []Check {
+	checks := make([]Check, 0, len(e.Answers))
+	for part := 1; part <= len(e.Answers); part++ {
+		expected := e.Expected(part)
+		if expected == "" || !r.Want(part) {
+			continue
+		}
+		check := Check{Part: part, Verdict: Fail, Expected: expected}
+		if result := r.Result(part); result != nil {
+			check.Answer = result.Answer()
+		}
+		if check.Answer == expected {
+			check.Verdict = Pass
+		}
+		checks = append(checks, check)
+	}
+	return checks
+}
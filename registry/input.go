@@ -0,0 +1,16 @@
+package registry
+
+import (
+	"io/ioutil"
+	"os"
+)
+
+// ReadInput reads a whole puzzle input into memory.
+//
+// The path "-" reads standard input.
+func ReadInput(path string) ([]byte, error) {
+	if path == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(path)
+}
@@ -0,0 +1,288 @@
+package registry
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// ErrHelp is returned by Run.Solve when the arguments ask for help.
+var ErrHelp = flag.ErrHelp
+
+// OptionError is returned by Run.Solve when the puzzle-specific arguments are
+// invalid, before the puzzle is run.
+type OptionError struct {
+	Day int
+	Err error
+}
+
+func (e *OptionError) Error() string {
+	return fmt.Sprintf("day %d: %v", e.Day, e.Err)
+}
+
+func (e *OptionError) Unwrap() error {
+	return e.Err
+}
+
+// Option declares a puzzle-specific command-line option, like "-n 10".
+type Option struct {
+	// Name without the leading dash. Options may be given with one dash or
+	// two, like the runner's own options.
+	Name string
+
+	// Other names for the option, such as a day's spelling from before it
+	// used the registry.
+	Aliases []string
+
+	// Default value, which also sets the type: bool, int or string.
+	Default interface{}
+
+	// Help text. A name in `backquotes` is used for the option's argument.
+	Usage string
+
+	// Optional validation for values given on the command line.
+	Check func(value interface{}) error
+}
+
+// Positive is an Option.Check for ints which must be greater than zero.
+func Positive(value interface{}) error {
+	if value.(int) <= 0 {
+		return errors.New("must be positive")
+	}
+	return nil
+}
+
+// NonNegative is an Option.Check for ints which must not be negative.
+func NonNegative(value interface{}) error {
+	if value.(int) < 0 {
+		return errors.New("must not be negative")
+	}
+	return nil
+}
+
+func (o *Option) define(fs *flag.FlagSet) {
+	switch value := o.Default.(type) {
+	case bool:
+		fs.Bool(o.Name, value, o.Usage)
+	case int:
+		fs.Int(o.Name, value, o.Usage)
+	case string:
+		fs.String(o.Name, value, o.Usage)
+	default:
+		panic(fmt.Sprintf("registry: option -%s has unsupported type %T",
+			o.Name, o.Default))
+	}
+	// Aliases share the option's value.
+	f := fs.Lookup(o.Name)
+	for _, alias := range o.Aliases {
+		fs.Var(f.Value, alias, o.Usage)
+	}
+}
+
+// Whether the option is called name, or has it as an alias.
+func (o *Option) named(name string) bool {
+	if o.Name == name {
+		return true
+	}
+	for _, alias := range o.Aliases {
+		if alias == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Synopsis of the option, like "[-n TURNS]".
+func (o *Option) synopsis() string {
+	if _, ok := o.Default.(bool); ok {
+		return fmt.Sprintf("[-%s]", o.Name)
+	}
+	name, _ := flag.UnquoteUsage(o.flag())
+	return fmt.Sprintf("[-%s %s]", o.Name, strings.ToUpper(name))
+}
+
+// A stand-alone flag for the option, for formatting its usage.
+func (o *Option) flag() *flag.Flag {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	o.define(fs)
+	return fs.Lookup(o.Name)
+}
+
+// Synopsis of the puzzle-specific options, like "[-n TURNS]", followed by
+// any positional arguments, like "[N [SUM]]".
+func (p *Puzzle) Synopsis() string {
+	parts := make([]string, len(p.Options), len(p.Options)+1)
+	for index := range p.Options {
+		parts[index] = p.Options[index].synopsis()
+	}
+	var positional string
+	for index := len(p.Positional) - 1; index >= 0; index-- {
+		name, _ := flag.UnquoteUsage(p.option(p.Positional[index]).flag())
+		if positional != "" {
+			name += " " + positional
+		}
+		positional = fmt.Sprintf("[%s]", strings.ToUpper(name))
+	}
+	if positional != "" {
+		parts = append(parts, positional)
+	}
+	return strings.Join(parts, " ")
+}
+
+// WriteOptions describes each puzzle-specific option, one per line, with the
+// given indentation.
+func (p *Puzzle) WriteOptions(w io.Writer, indent string) {
+	fs := p.flagSet()
+	fs.SetOutput(w)
+	for index := range p.Options {
+		o := &p.Options[index]
+		f := fs.Lookup(o.Name)
+		name, usage := flag.UnquoteUsage(f)
+		fmt.Fprint(w, indent)
+		for index, alias := range append([]string{o.Name}, o.Aliases...) {
+			if index > 0 {
+				fmt.Fprint(w, ", ")
+			}
+			fmt.Fprintf(w, "-%s", alias)
+			if name != "" {
+				fmt.Fprintf(w, " %s", name)
+			}
+		}
+		fmt.Fprintf(w, "\n%s    %s", indent, usage)
+		if f.DefValue != "" && f.DefValue != "0" && f.DefValue != "false" {
+			fmt.Fprintf(w, " (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w)
+	}
+}
+
+// WriteUsage writes the puzzle's help: its synopsis, description and
+// options. The command is how to run the puzzle, like "advent2020 15".
+func (p *Puzzle) WriteUsage(w io.Writer, command string) {
+	fmt.Fprintf(w, "usage: %s %s\n", command, p.Synopsis())
+	fmt.Fprintf(w, "\nDay %d: %s.\n", p.Day, p.Title)
+	if p.Help != "" {
+		fmt.Fprintf(w, "%s\n", p.Help)
+	}
+	if len(p.Options) > 0 {
+		fmt.Fprintf(w, "\nOptions:\n")
+		p.WriteOptions(w, "  ")
+	}
+}
+
+// A new flag set with the puzzle's options.
+func (p *Puzzle) flagSet() *flag.FlagSet {
+	fs := flag.NewFlagSet(p.Name(), flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.Usage = func() {}
+	for index := range p.Options {
+		p.Options[index].define(fs)
+	}
+	return fs
+}
+
+// Check the puzzle's option declarations, for Register.
+func (p *Puzzle) checkOptions() {
+	seen := make(map[string]bool, len(p.Options))
+	for index := range p.Options {
+		o := &p.Options[index]
+		for _, name := range append([]string{o.Name}, o.Aliases...) {
+			if name == "" || name == "h" || name == "help" || seen[name] {
+				panic(fmt.Sprintf("registry: day %d has invalid option '-%s'",
+					p.Day, name))
+			}
+			seen[name] = true
+		}
+		o.flag() // panics for unsupported types
+	}
+	for _, name := range p.Positional {
+		if p.option(name) == nil {
+			panic(fmt.Sprintf("registry: day %d has no option -%s for its "+
+				"positional arguments", p.Day, name))
+		}
+	}
+}
+
+func (p *Puzzle) option(name string) *Option {
+	for index := range p.Options {
+		if p.Options[index].named(name) {
+			return &p.Options[index]
+		}
+	}
+	return nil
+}
+
+// Parse the run's arguments into its options.
+//
+// Returns ErrHelp if they ask for help.
+func (r *Run) parseOptions() error {
+	fs := r.Puzzle.flagSet()
+	if err := fs.Parse(r.Args); err != nil {
+		if err == flag.ErrHelp {
+			return ErrHelp
+		}
+		return &OptionError{r.Puzzle.Day, err}
+	}
+	// Positional arguments set the options they stand for, in order.
+	args := fs.Args()
+	for index, name := range r.Puzzle.Positional {
+		if index == len(args) {
+			break
+		}
+		if err := fs.Set(name, args[index]); err != nil {
+			return &OptionError{r.Puzzle.Day,
+				fmt.Errorf("invalid value %q for %s: %v", args[index],
+					strings.ToUpper(name), err)}
+		}
+	}
+	if len(args) > len(r.Puzzle.Positional) {
+		return &OptionError{r.Puzzle.Day, fmt.Errorf("unexpected argument '%s'",
+			args[len(r.Puzzle.Positional)])}
+	}
+
+	var err error
+	fs.Visit(func(f *flag.Flag) {
+		o := r.Puzzle.option(f.Name)
+		if err != nil || o.Check == nil {
+			return
+		}
+		if checkErr := o.Check(f.Value.(flag.Getter).Get()); checkErr != nil {
+			err = &OptionError{r.Puzzle.Day, fmt.Errorf(
+				"invalid value %s for -%s: %v", f.Value, f.Name, checkErr)}
+		}
+	})
+	r.options = fs
+	return err
+}
+
+// Value of a puzzle-specific option. Panics if the puzzle does not declare it.
+func (r *Run) option(name string) interface{} {
+	if r.options != nil {
+		if f := r.options.Lookup(name); f != nil {
+			return f.Value.(flag.Getter).Get()
+		}
+	}
+	o := r.Puzzle.option(name)
+	if o == nil {
+		panic(fmt.Sprintf("registry: day %d has no option -%s",
+			r.Puzzle.Day, name))
+	}
+	return o.Default
+}
+
+// Bool returns the value of a bool option.
+func (r *Run) Bool(name string) bool {
+	return r.option(name).(bool)
+}
+
+// Int returns the value of an int option.
+func (r *Run) Int(name string) int {
+	return r.option(name).(int)
+}
+
+// String returns the value of a string option.
+func (r *Run) String(name string) string {
+	return r.option(name).(string)
+}
@@ -0,0 +1,168 @@
+// Package registry keeps track of the puzzle solvers for each day.
+//
+// Each day's package registers a Puzzle descriptor from its init function.
+// The runner imports the day packages for their side effects only and
+// dispatches through the registry, so adding a day never requires editing
+// the runner itself.
+package registry
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"sort"
+
+	"github.com/fritzr/advent2020/logging"
+)
+
+// Main is the entry point of a puzzle solver.
+//
+// The solver reads its parameters from the Run and reports each answer with
+// Run.Answer rather than printing it.
+//
+// The context is cancelled when the run times out or is interrupted. Solvers
+// with long-running loops should check it periodically and give up with an
+// error which wraps ctx.Err() and says how far they got. Answers reported
+// before that are kept.
+type Main func(ctx context.Context, r *Run) error
+
+// Status records how much of a puzzle is implemented.
+type Status int
+
+const (
+	// Implemented puzzles solve every part listed in Puzzle.Parts.
+	// This is the zero value, so most days need not set it explicitly.
+	Implemented Status = iota
+	// Unimplemented puzzles are placeholders which solve nothing yet.
+	Unimplemented
+)
+
+var statusString = [...]string{"implemented", "unimplemented"}
+
+func (s Status) String() string {
+	if s < 0 || int(s) >= len(statusString) {
+		return fmt.Sprintf("Status(%d)", int(s))
+	}
+	return statusString[s]
+}
+
+// Puzzle describes a single day's puzzle.
+type Puzzle struct {
+	Day   int
+	Title string
+
+	// Number of parts solved by Main.
+	Parts int
+
+	// Default input path, relative to the repository root.
+	// If empty, Register fills in pNN/input.
+	Input string
+
+	// A description of what the puzzle does and how its options change that,
+	// for --help.
+	Help string
+
+	// Puzzle-specific options, parsed from Run.Args before Main is called.
+	Options []Option
+
+	// Names of options which may also be given as positional arguments after
+	// the options, in order, like "1 3 2020" for "1 -n 3 -sum 2020".
+	Positional []string
+
+	// Sample inputs from the puzzle description, selected with --example N.
+	Examples []Example
+
+	Status Status
+	Main   Main
+
+	// Optional interactive commands for the runner's repl.
+	Repl Repl
+}
+
+// NewRun prepares to run the puzzle on buffered input data.
+//
+// The input names where the data came from, such as a path or "-" for
+// standard input.
+func (p *Puzzle) NewRun(input string, data []byte) *Run {
+	return &Run{Puzzle: p, Input: input, Data: data}
+}
+
+// Solve runs the puzzle on the given input file and collects its results.
+//
+// The path "-" reads standard input. The run logs to a sub-logger of log named
+// after the puzzle; log may be nil.
+// The returned Run holds whatever results were reported even on error.
+func (p *Puzzle) Solve(ctx context.Context, input string, log *logging.Logger,
+	args []string) (*Run, error) {
+	data, err := ReadInput(input)
+	r := p.NewRun(input, data)
+	r.Log = log.Sub(p.Name())
+	r.Args = args
+	if err != nil {
+		return r, err
+	}
+	return r, r.Solve(ctx)
+}
+
+// Name returns the puzzle's package name, like "p01".
+func (p *Puzzle) Name() string {
+	return fmt.Sprintf("p%02d", p.Day)
+}
+
+func (p *Puzzle) Implemented() bool {
+	return p.Status == Implemented
+}
+
+var puzzles = make(map[int]*Puzzle, 25)
+
+// Register a puzzle.
+//
+// Register is meant to be called from init functions, so it panics when the
+// descriptor is invalid or the day was already registered.
+func Register(p Puzzle) {
+	if p.Day < 1 || p.Day > 25 {
+		panic(fmt.Sprintf("registry: invalid day %d", p.Day))
+	}
+	if p.Main == nil {
+		panic(fmt.Sprintf("registry: day %d has no Main", p.Day))
+	}
+	if _, dup := puzzles[p.Day]; dup {
+		panic(fmt.Sprintf("registry: day %d registered twice", p.Day))
+	}
+	if p.Input == "" {
+		p.Input = path.Join(p.Name(), "input")
+	}
+	p.checkOptions()
+	puzzles[p.Day] = &p
+}
+
+// Lookup the puzzle registered for the given day.
+func Lookup(day int) (*Puzzle, error) {
+	p := puzzles[day]
+	if p == nil {
+		return nil, fmt.Errorf("unimplemented day '%d'", day)
+	}
+	return p, nil
+}
+
+// All returns every registered puzzle ordered by day.
+func All() []*Puzzle {
+	all := make([]*Puzzle, 0, len(puzzles))
+	for _, p := range puzzles {
+		all = append(all, p)
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].Day < all[j].Day })
+	return all
+}
+
+// Latest returns the implemented puzzle with the highest day number,
+// or nil if no puzzles are implemented.
+func Latest() *Puzzle {
+	var latest *Puzzle
+	for _, p := range puzzles {
+		if p.Implemented() && (latest == nil || p.Day > latest.Day) {
+			latest = p
+		}
+	}
+	return latest
+}
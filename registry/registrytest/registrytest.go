@@ -0,0 +1,169 @@
+// Package registrytest runs registered puzzles from Go tests.
+//
+// Each day's package has a test file which checks the day against the
+// examples from its puzzle description and against the golden answers to its
+// real input, which are kept in answers.txt at the repository root:
+//
+//	func TestExamples(t *testing.T) { registrytest.Examples(t, 1) }
+//	func TestInput(t *testing.T)    { registrytest.Input(t, 1) }
+//
+// Fuzz targets for the days' parsers seed their corpus with InputData or
+// InputLines.
+package registrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/fritzr/advent2020/registry"
+)
+
+// Name of the golden answers file at the repository root.
+const AnswersFile = "answers.txt"
+
+// Examples runs the puzzle for the given day on each of its examples and
+// checks the answers given for them, one subtest per example.
+func Examples(t *testing.T, day int) {
+	t.Helper()
+	puzzle := lookup(t, day)
+	if len(puzzle.Examples) == 0 {
+		t.Skipf("day %d has no examples", day)
+	}
+	for index := range puzzle.Examples {
+		n := index + 1
+		t.Run(fmt.Sprintf("example %d", n), func(t *testing.T) {
+			run, err := puzzle.NewExampleRun(n)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err = run.Solve(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			example := &puzzle.Examples[index]
+			for _, check := range example.Check(run) {
+				if check.Verdict != registry.Pass {
+					t.Errorf("%s", check)
+				}
+			}
+		})
+	}
+}
+
+// Input runs the puzzle for the given day on its real input and compares
+// the answers to the golden answers.
+//
+// Skipped in short mode, since some days take a few seconds.
+func Input(t *testing.T, day int) {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("skipping the real input in short mode")
+	}
+	puzzle := lookup(t, day)
+	root, err := Root()
+	if err != nil {
+		t.Fatal(err)
+	}
+	answers, err := registry.ReadAnswersFromFile(
+		filepath.Join(root, AnswersFile))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasAnswers(answers, day) {
+		t.Skipf("no golden answers for day %d in %s", day, AnswersFile)
+	}
+
+	data, err := registry.ReadInput(filepath.Join(root, puzzle.Input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := puzzle.NewRun(puzzle.Input, data)
+	if err = run.Solve(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	for _, check := range answers.Check(run) {
+		if check.Verdict != registry.Pass {
+			t.Errorf("%s", check)
+		}
+	}
+}
+
+// Solve runs the puzzle for the given day on the given input and returns its
+// error, for example to check that it rejects invalid input.
+func Solve(tb testing.TB, day int, input string) error {
+	tb.Helper()
+	puzzle, err := registry.Lookup(day)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return puzzle.NewRun("input", []byte(input)).Solve(context.Background())
+}
+
+// InputData returns the real input for the given day, for example to seed a
+// fuzz corpus. The input is empty if the day has none.
+func InputData(tb testing.TB, day int) []byte {
+	tb.Helper()
+	puzzle, err := registry.Lookup(day)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	root, err := Root()
+	if err != nil {
+		tb.Fatal(err)
+	}
+	data, err := registry.ReadInput(filepath.Join(root, puzzle.Input))
+	if err != nil && !os.IsNotExist(err) {
+		tb.Fatal(err)
+	}
+	return data
+}
+
+// InputLines returns the lines of the real input for the given day.
+func InputLines(tb testing.TB, day int) []string {
+	tb.Helper()
+	return strings.Split(strings.TrimRight(string(InputData(tb, day)), "\n"), "\n")
+}
+
+// Root finds the repository root: the closest directory containing go.mod,
+// starting from the working directory.
+func Root() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, "go.mod")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("go.mod not found")
+		}
+		dir = parent
+	}
+}
+
+func lookup(t *testing.T, day int) *registry.Puzzle {
+	t.Helper()
+	puzzle, err := registry.Lookup(day)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !puzzle.Implemented() {
+		t.Skipf("day %d is %s", day, puzzle.Status)
+	}
+	return puzzle
+}
+
+func hasAnswers(answers registry.Answers, day int) bool {
+	for key := range answers {
+		if key.Day == day {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/fritzr/advent2020/util"
+)
+
+// ErrUsage is returned by a Command given the wrong arguments. The caller
+// then describes how to use the command.
+var ErrUsage = errors.New("invalid arguments")
+
+// Command explores the data structures of a puzzle in an interactive session,
+// like "contains shiny gold".
+type Command struct {
+	Name string
+
+	// Synopsis of the arguments, like "BAG" or "[N]".
+	Args string
+
+	// Help text.
+	Usage string
+
+	// Run the command with the words after its name, writing to w.
+	// Long-running commands should give up when the context is cancelled.
+	Run func(ctx context.Context, w io.Writer, args []string) error
+}
+
+// Repl starts an interactive session on a puzzle's input: it parses the input
+// and returns the commands which explore the result.
+//
+// Like Main, it reads its parameters from the Run, but it reports no answers.
+type Repl func(ctx context.Context, r *Run) ([]Command, error)
+
+// StartRepl parses the run's options and starts an interactive session on its
+// input with the puzzle's Repl.
+func (r *Run) StartRepl(ctx context.Context) ([]Command, error) {
+	if r.Puzzle.Repl == nil {
+		return nil, fmt.Errorf("day %d has no interactive commands", r.Puzzle.Day)
+	}
+	if err := r.parseOptions(); err != nil {
+		return nil, err
+	}
+	commands, err := r.Puzzle.Repl(ctx, r)
+
+	// Say which input a problem was found in.
+	var parseErr *util.ParseError
+	if errors.As(err, &parseErr) && parseErr.Input == "" {
+		parseErr.Input = r.Input
+	}
+	return commands, err
+}
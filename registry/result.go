@@ -0,0 +1,180 @@
+package registry
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/fritzr/advent2020/logging"
+	"github.com/fritzr/advent2020/util"
+)
+
+// Result is a single answer reported by a puzzle.
+type Result struct {
+	// Puzzle part, starting from 1.
+	// Part 0 is used for answers to custom queries made via puzzle arguments.
+	Part int
+
+	// Short description of what the answer means.
+	Label string
+
+	// The answer itself, usually an int, int64 or uint64.
+	Value interface{}
+
+	// Optional supporting details, one per line.
+	Details []string
+
+	// Time spent solving the part, measured since the input was parsed or
+	// the previous answer was reported.
+	Elapsed time.Duration
+}
+
+// Answer returns the value formatted as it should be submitted.
+func (res *Result) Answer() string {
+	return fmt.Sprint(res.Value)
+}
+
+func (res Result) String() string {
+	var s strings.Builder
+	if res.Part > 0 {
+		fmt.Fprintf(&s, "Part %d: ", res.Part)
+	}
+	fmt.Fprintf(&s, "%s: %s", res.Label, res.Answer())
+	for _, detail := range res.Details {
+		s.WriteString("\n  ")
+		s.WriteString(detail)
+	}
+	return s.String()
+}
+
+// Run holds the parameters and results of a single execution of a puzzle.
+type Run struct {
+	Puzzle *Puzzle
+
+	// Where the puzzle input came from, usually a path.
+	Input string
+
+	// The puzzle input, buffered in memory.
+	// Solvers should read it through Open.
+	Data []byte
+
+	// Logger for the solver, named after the puzzle like "p08". It may be
+	// nil, which discards everything.
+	Log *logging.Logger
+
+	// Puzzle-specific arguments, parsed into the options declared by the
+	// puzzle when it is solved. Read them with Bool, Int and String.
+	Args []string
+
+	// The only part to solve, or 0 to solve every part.
+	// Answers to other parts are dropped; solvers may check Want to skip the
+	// work for them entirely.
+	Part int
+
+	// Answers reported so far, in order.
+	Results []Result
+
+	// Time spent parsing the input, if the solver marked it with Parsed.
+	ParseTime time.Duration
+
+	// Total time spent in the solver.
+	Elapsed time.Duration
+
+	// When the solver started, and the start of the current lap: the time the
+	// solver started, parsed its input, or reported its last answer.
+	started time.Time
+	lap     time.Time
+
+	// Parsed options, once solving has started.
+	options *flag.FlagSet
+}
+
+// Solve runs the puzzle, collecting its results in the Run.
+//
+// Returns ErrHelp without running the puzzle if the arguments ask for help.
+// A util.ParseError returned by the puzzle is given the name of the input.
+func (r *Run) Solve(ctx context.Context) error {
+	if err := r.parseOptions(); err != nil {
+		return err
+	}
+	r.start()
+	err := r.Puzzle.Main(ctx, r)
+	r.stop()
+
+	// Say which input a problem was found in.
+	var parseErr *util.ParseError
+	if errors.As(err, &parseErr) && parseErr.Input == "" {
+		parseErr.Input = r.Input
+	}
+	return err
+}
+
+// Open returns a new reader over the whole puzzle input.
+//
+// The reader supports seeking, even if the input came from a pipe.
+func (r *Run) Open() *bytes.Reader {
+	return bytes.NewReader(r.Data)
+}
+
+// Start timing the run.
+func (r *Run) start() {
+	r.started = time.Now()
+	r.lap = r.started
+}
+
+// Stop timing the run.
+func (r *Run) stop() {
+	r.Elapsed = time.Since(r.started)
+}
+
+// Time since the last lap; starts a new lap.
+func (r *Run) nextLap() time.Duration {
+	if r.lap.IsZero() {
+		return 0
+	}
+	now := time.Now()
+	elapsed := now.Sub(r.lap)
+	r.lap = now
+	return elapsed
+}
+
+// Parsed marks the end of parsing the input.
+//
+// The time until this call is recorded as the ParseTime. Without it, time
+// spent parsing counts towards the first answer.
+func (r *Run) Parsed() {
+	r.ParseTime += r.nextLap()
+}
+
+// Want reports whether the given part should be solved.
+func (r *Run) Want(part int) bool {
+	return r.Part == 0 || r.Part == part
+}
+
+// Answer reports the answer to a part of the puzzle.
+//
+// Answers to parts which are not wanted are ignored. Answers to custom
+// queries (part 0) are always kept.
+func (r *Run) Answer(part int, label string, value interface{},
+	details ...string) {
+	if part != 0 && !r.Want(part) {
+		r.nextLap()
+		return
+	}
+	r.Results = append(r.Results,
+		Result{part, label, value, details, r.nextLap()})
+}
+
+// Result returns the first answer reported for the given part, if any.
+func (r *Run) Result(part int) *Result {
+	for index := range r.Results {
+		if r.Results[index].Part == part {
+			return &r.Results[index]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,196 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/fritzr/advent2020/registry"
+)
+
+// Run an interactive session on a day's input until the end of standard
+// input or "quit".
+//
+// The input is read and parsed once, from -i PATH, --example N or the day's
+// default input. Further arguments are the puzzle's options.
+func replCommand(args []string) error {
+	if len(args) == 0 {
+		return errors.New("repl: expected a day")
+	}
+	day, err := strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("repl: invalid day '%s'", args[0])
+	}
+	puzzle, err := registry.Lookup(day)
+	if err != nil {
+		return err
+	}
+	if puzzle.Repl == nil {
+		return fmt.Errorf("repl: day %d (%s) has no interactive commands",
+			puzzle.Day, puzzle.Title)
+	}
+	run, err := replRun(puzzle, args[1:])
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := commandContext()
+	commands, err := run.StartRepl(ctx)
+	cancel()
+	if errors.Is(err, registry.ErrHelp) {
+		command := fmt.Sprintf("%s [OPTIONS...] repl %d",
+			path.Base(os.Args[0]), puzzle.Day)
+		puzzle.WriteUsage(os.Stdout, command)
+		return nil
+	}
+	if err != nil {
+		fmt.Fprint(os.Stderr, errorSnippet(&dayRun{puzzle, run, err, 0}))
+		return err
+	}
+	fmt.Printf("Day %d: %s (%s). Type 'help' for commands.\n", puzzle.Day,
+		puzzle.Title, run.Input)
+	return repl(os.Stdin, os.Stdout, fmt.Sprintf("%s> ", puzzle.Name()),
+		commands)
+}
+
+// Prepare a run of the day on the input selected for the repl.
+func replRun(puzzle *registry.Puzzle, args []string) (*registry.Run, error) {
+	if example > 0 {
+		run, err := puzzle.NewExampleRun(example)
+		if err != nil {
+			return nil, err
+		}
+		run.Log = logger.Sub(puzzle.Name())
+		if len(args) > 0 {
+			run.Args = args
+		}
+		return run, nil
+	}
+
+	path := puzzle.Input
+	if len(inputs) > 1 {
+		return nil, errors.New("repl: only one input may be given with -i")
+	} else if len(inputs) == 1 {
+		path = inputs[0]
+	}
+	if path == "-" {
+		return nil, errors.New("repl: commands are read from stdin, so the " +
+			"input cannot be")
+	}
+	data, err := registry.ReadInput(path)
+	if err != nil {
+		return nil, err
+	}
+	return newRun(puzzle, path, data, args, logger), nil
+}
+
+// Context for a single command, limited by --timeout if given.
+//
+// The runner's own context is cancelled for good on the first interrupt, so
+// commands are interrupted separately; see repl.
+func commandContext() (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(context.Background(), timeout)
+	}
+	return context.WithCancel(context.Background())
+}
+
+// Read commands from r and run them, writing their output to w.
+//
+// An interrupt cancels the command being run. Errors from a command are
+// reported, and the session goes on.
+func repl(r io.Reader, w io.Writer, prompt string,
+	commands []registry.Command) error {
+	byName := make(map[string]*registry.Command, len(commands))
+	for index := range commands {
+		byName[commands[index].Name] = &commands[index]
+	}
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	defer signal.Stop(interrupt)
+
+	scanner := bufio.NewScanner(r)
+	for {
+		fmt.Fprint(w, prompt)
+		if !scanner.Scan() {
+			fmt.Fprintln(w)
+			return scanner.Err()
+		}
+		words := strings.Fields(scanner.Text())
+		if len(words) == 0 {
+			continue
+		}
+		name, args := words[0], words[1:]
+		switch name {
+		case "quit", "exit":
+			return nil
+		case "help":
+			replHelp(w, commands, args)
+			continue
+		}
+		command := byName[name]
+		if command == nil {
+			fmt.Fprintf(w, "unknown command '%s'; try 'help'\n", name)
+			continue
+		}
+
+		// Forget interrupts from the prompt, then cancel the command on the
+		// next one.
+		select {
+		case <-interrupt:
+		default:
+		}
+		ctx, cancel := commandContext()
+		done := make(chan struct{})
+		go func() {
+			select {
+			case <-interrupt:
+				cancel()
+			case <-done:
+			}
+		}()
+		err := command.Run(ctx, w, args)
+		close(done)
+		cancel()
+
+		if errors.Is(err, registry.ErrUsage) {
+			fmt.Fprintf(w, "usage: %s %s\n", command.Name, command.Args)
+		} else if err != nil {
+			fmt.Fprintf(w, "error: %v\n", err)
+		}
+	}
+}
+
+// Describe the given commands, or all of them.
+func replHelp(w io.Writer, commands []registry.Command, names []string) {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	for _, command := range commands {
+		if len(names) > 0 && !containsString(names, command.Name) {
+			continue
+		}
+		fmt.Fprintf(tw, "  %s\t%s\n",
+			strings.TrimSpace(command.Name+" "+command.Args), command.Usage)
+	}
+	if len(names) == 0 {
+		fmt.Fprintf(tw, "  %s\t%s\n", "help [COMMAND...]", "describe commands")
+		fmt.Fprintf(tw, "  %s\t%s\n", "quit", "end the session (or EOF)")
+	}
+	tw.Flush()
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,249 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/fritzr/advent2020/registry"
+)
+
+// Limits for each request made to the HTTP service.
+type serveLimits struct {
+	timeout time.Duration
+	maxBody int64
+}
+
+// How long to wait for a day to stop once its request is cancelled.
+const solveGrace = 100 * time.Millisecond
+
+// Wrapped by the error for a day which panicked.
+var errPanic = errors.New("panic")
+
+// A puzzle as listed by GET /days.
+type puzzleRecord struct {
+	Day      int      `json:"day"`
+	Title    string   `json:"title"`
+	Parts    int      `json:"parts"`
+	Status   string   `json:"status"`
+	Synopsis string   `json:"synopsis,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
+
+// Run the HTTP service until the runner is interrupted.
+//
+// GET /days lists the registered puzzles. POST /days/N solves day N on the
+// request body, and returns the same records as --format=json. The query
+// parameter part=N solves only that part; any other parameter name=value is
+// passed to the puzzle as the option -name=value.
+func serve(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := fs.String("addr", "localhost:8080", "listen on `address`")
+	limits := serveLimits{}
+	fs.DurationVar(&limits.timeout, "timeout", 30*time.Second,
+		"abort each request after `duration`")
+	fs.Int64Var(&limits.maxBody, "max-body", 1<<20,
+		"reject inputs larger than `bytes`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 0 {
+		return fmt.Errorf("serve: unexpected argument '%s'", fs.Arg(0))
+	}
+	if limits.timeout <= 0 || limits.maxBody <= 0 {
+		return errors.New("serve: --timeout and --max-body must be positive")
+	}
+
+	server := &http.Server{
+		Addr:              *addr,
+		Handler:           newServeMux(limits),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-runCtx.Done()
+		ctx, cancel := context.WithTimeout(context.Background(), limits.timeout)
+		defer cancel()
+		server.Shutdown(ctx)
+	}()
+	log.Printf("serving %d puzzles on %s", len(registry.All()), *addr)
+	if err := server.ListenAndServe(); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+func newServeMux(limits serveLimits) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/days", handleList)
+	mux.HandleFunc("/days/", func(w http.ResponseWriter, req *http.Request) {
+		handleSolve(w, req, limits)
+	})
+	return mux
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	encoder.Encode(value)
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSONResponse(w, status, map[string]string{"error": err.Error()})
+}
+
+func handleList(w http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		writeJSONError(w, http.StatusMethodNotAllowed,
+			fmt.Errorf("method %s not allowed", req.Method))
+		return
+	}
+	puzzles := registry.All()
+	records := make([]puzzleRecord, 0, len(puzzles))
+	for _, p := range puzzles {
+		options := make([]string, 0, len(p.Options))
+		for _, o := range p.Options {
+			options = append(options, o.Name)
+		}
+		records = append(records, puzzleRecord{Day: p.Day, Title: p.Title,
+			Parts: p.Parts, Status: p.Status.String(), Synopsis: p.Synopsis(),
+			Options: options})
+	}
+	writeJSONResponse(w, http.StatusOK, records)
+}
+
+// Puzzle arguments from the query parameters of a request.
+func queryArgs(req *http.Request) (runPart int, args []string, err error) {
+	query := req.URL.Query()
+	names := make([]string, 0, len(query))
+	for name := range query {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		for _, value := range query[name] {
+			if name == "part" {
+				runPart, err = strconv.Atoi(value)
+				if err != nil || runPart < 0 {
+					return 0, nil, fmt.Errorf("invalid part '%s'", value)
+				}
+				continue
+			}
+			args = append(args, fmt.Sprintf("-%s=%s", name, value))
+		}
+	}
+	return runPart, args, nil
+}
+
+func handleSolve(w http.ResponseWriter, req *http.Request, limits serveLimits) {
+	if req.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		writeJSONError(w, http.StatusMethodNotAllowed,
+			fmt.Errorf("method %s not allowed", req.Method))
+		return
+	}
+	day, err := strconv.Atoi(strings.TrimPrefix(req.URL.Path, "/days/"))
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound,
+			fmt.Errorf("invalid day '%s'", req.URL.Path))
+		return
+	}
+	puzzle, err := registry.Lookup(day)
+	if err != nil {
+		writeJSONError(w, http.StatusNotFound, err)
+		return
+	}
+	if !puzzle.Implemented() {
+		writeJSONError(w, http.StatusNotImplemented,
+			fmt.Errorf("day %d is %s", day, puzzle.Status))
+		return
+	}
+	runPart, args, err := queryArgs(req)
+	if err == nil && runPart > puzzle.Parts {
+		err = fmt.Errorf("day %d has no part %d", day, runPart)
+	}
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// Read one byte past the limit to tell whether the input exceeds it.
+	data, err := io.ReadAll(io.LimitReader(req.Body, limits.maxBody+1))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	if int64(len(data)) > limits.maxBody {
+		writeJSONError(w, http.StatusRequestEntityTooLarge,
+			fmt.Errorf("input is larger than %d bytes", limits.maxBody))
+		return
+	}
+
+	run := puzzle.NewRun("request", data)
+	run.Log = logger.Sub(puzzle.Name())
+	run.Args = args
+	run.Part = runPart
+	ctx, cancel := context.WithTimeout(req.Context(), limits.timeout)
+	defer cancel()
+
+	// A day which never checks ctx would otherwise hold the request past the
+	// deadline, so give up on it after a short grace period. The channel is
+	// buffered so that an abandoned Solve can still finish and exit.
+	solved := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				log.Printf("day %d panicked: %v\n%s", day, p, debug.Stack())
+				solved <- fmt.Errorf("day %d: %w: %v", day, errPanic, p)
+			}
+		}()
+		solved <- run.Solve(ctx)
+	}()
+	select {
+	case err = <-solved:
+	case <-ctx.Done():
+		// A day which stops once it is cancelled still answers with whatever
+		// it finished; the run must not be read while it is still running.
+		select {
+		case err = <-solved:
+		case <-time.After(solveGrace):
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				writeJSONError(w, http.StatusGatewayTimeout,
+					fmt.Errorf("day %d did not finish within %v", day,
+						limits.timeout))
+			} else {
+				writeJSONError(w, http.StatusServiceUnavailable, ctx.Err())
+			}
+			return
+		}
+	}
+
+	var optionErr *registry.OptionError
+	status := http.StatusOK
+	switch {
+	case err == nil:
+	case errors.Is(err, registry.ErrHelp) || errors.As(err, &optionErr):
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	case errors.Is(err, context.DeadlineExceeded):
+		status = http.StatusGatewayTimeout
+	case errors.Is(err, errPanic):
+		status = http.StatusInternalServerError
+	default:
+		status = http.StatusUnprocessableEntity
+	}
+	d := dayRun{puzzle, run, err, run.Elapsed}
+	writeJSONResponse(w, status, resultRecords(&d))
+}
@@ -0,0 +1,32 @@
+package main
+
+import (
+	"context"
+	"os"
+	"os/signal"
+)
+
+// Context for the whole runner. It is cancelled on the first interrupt, so a
+// day which observes cancellation can stop and report how far it got. A
+// second interrupt kills the runner as usual.
+var runCtx = context.Background()
+
+func handleInterrupt() {
+	ctx, cancel := context.WithCancel(context.Background())
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+	go func() {
+		<-interrupt
+		signal.Stop(interrupt)
+		cancel()
+	}()
+	runCtx = ctx
+}
+
+// Context for a single run of a day, limited by --timeout if given.
+func dayContext() (context.Context, context.CancelFunc) {
+	if timeout > 0 {
+		return context.WithTimeout(runCtx, timeout)
+	}
+	return context.WithCancel(runCtx)
+}
@@ -0,0 +1,174 @@
+package util
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// ParseError is a problem with the puzzle input at a position.
+type ParseError struct {
+	// Name of the input, like "p04/input". The registry fills it in for
+	// errors returned from a puzzle.
+	Input string
+
+	// Line number and column (in bytes) of the problem, from 1. Either may be
+	// 0 if unknown.
+	Line   int
+	Column int
+
+	// The offending text.
+	Text string
+
+	Err error
+}
+
+func (e *ParseError) Error() string {
+	var msg strings.Builder
+	msg.WriteString(e.Input)
+	if e.Input == "" {
+		msg.WriteString("input")
+	}
+	if e.Line > 0 {
+		fmt.Fprintf(&msg, ":%d", e.Line)
+		if e.Column > 0 {
+			fmt.Fprintf(&msg, ":%d", e.Column)
+		}
+	}
+	fmt.Fprintf(&msg, ": %v", e.Err)
+	if e.Text != "" {
+		fmt.Fprintf(&msg, ": %q", e.Text)
+	}
+	return msg.String()
+}
+
+func (e *ParseError) Unwrap() error {
+	return e.Err
+}
+
+// NewParseError returns an error for the text at a position.
+//
+// Errors from strconv already quote the text, so only their cause is kept.
+func NewParseError(line int, column int, text string, err error) *ParseError {
+	var numErr *strconv.NumError
+	if errors.As(err, &numErr) {
+		err = numErr.Err
+	}
+	return &ParseError{Line: line, Column: column, Text: text, Err: err}
+}
+
+// ParseErrorf returns an error for the text at a position, with a formatted
+// message.
+func ParseErrorf(line int, column int, text string, format string,
+	args ...interface{}) *ParseError {
+	return NewParseError(line, column, text, fmt.Errorf(format, args...))
+}
+
+// ErrorAt returns an error for the text at an offset into a piece of the
+// input, like a group of lines. The position is relative to the piece; see
+// AtLine.
+func ErrorAt(piece string, offset int, text string, err error) *ParseError {
+	line := 1 + strings.Count(piece[:offset], "\n")
+	column := offset + 1
+	if start := strings.LastIndexByte(piece[:offset], '\n'); start >= 0 {
+		column = offset - start
+	}
+	return NewParseError(line, column, text, err)
+}
+
+// AtLine places an error from a piece of the input which starts at the given
+// line. The line of a ParseError is made relative to the whole input; any
+// other error becomes a ParseError for the whole line.
+func AtLine(err error, line int) error {
+	if err == nil {
+		return nil
+	}
+	var parseErr *ParseError
+	if errors.As(err, &parseErr) {
+		if parseErr.Line > 0 {
+			parseErr.Line += line - 1
+		} else {
+			parseErr.Line = line
+		}
+		return err
+	}
+	return NewParseError(line, 0, "", err)
+}
+
+// A whitespace-separated field of a string and its byte offset.
+type Field struct {
+	Text   string
+	Offset int
+}
+
+// FieldsAt splits a string around whitespace like strings.Fields, and also
+// returns where each field begins.
+func FieldsAt(s string) []Field {
+	fields := make([]Field, 0, 8)
+	start := -1
+	for index, c := range s {
+		if unicode.IsSpace(c) {
+			if start >= 0 {
+				fields = append(fields, Field{s[start:index], start})
+				start = -1
+			}
+		} else if start < 0 {
+			start = index
+		}
+	}
+	if start >= 0 {
+		fields = append(fields, Field{s[start:], start})
+	}
+	return fields
+}
+
+// SplitAt splits a string around a separator like strings.Split, and also
+// returns where each part begins.
+func SplitAt(s string, sep string) []Field {
+	parts := strings.Split(s, sep)
+	fields := make([]Field, len(parts))
+	offset := 0
+	for index, part := range parts {
+		fields[index] = Field{part, offset}
+		offset += len(part) + len(sep)
+	}
+	return fields
+}
+
+// Snippet shows the line of the input with the problem, and marks the
+// offending text with carets:
+//
+//	3 | ecl:gry pid:860033327 eyr2020 hcl:#fffffd
+//	  |                       ^^^^^^^
+//
+// Returns "" if the line is not in the input.
+func (e *ParseError) Snippet(data []byte) string {
+	lines := strings.Split(string(data), "\n")
+	if e.Line < 1 || e.Line > len(lines) {
+		return ""
+	}
+	line := strings.TrimSuffix(lines[e.Line-1], "\r")
+	gutter := len(strconv.Itoa(e.Line))
+
+	var snippet strings.Builder
+	fmt.Fprintf(&snippet, " %*d | %s\n", gutter, e.Line, line)
+	if e.Column < 1 || e.Column > len(line)+1 {
+		return snippet.String()
+	}
+	// Keep tabs before the column so the carets line up.
+	indent := []byte(line[:e.Column-1])
+	for index, c := range indent {
+		if c != '\t' {
+			indent[index] = ' '
+		}
+	}
+	width := len(e.Text)
+	if width < 1 || e.Column-1+width > len(line) {
+		width = 1
+	}
+	fmt.Fprintf(&snippet, " %*s | %s%s\n", gutter, "", indent,
+		strings.Repeat("^", width))
+	return snippet.String()
+}
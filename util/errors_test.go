@@ -0,0 +1,111 @@
+package util
+
+import (
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestParseError(t *testing.T) {
+	errBad := errors.New("bad")
+	tests := []struct {
+		name string
+		err  *ParseError
+		want string
+	}{
+		{"position", &ParseError{"p04/input", 3, 7, "x", errBad},
+			`p04/input:3:7: bad: "x"`},
+		{"no input", &ParseError{"", 3, 7, "x", errBad}, `input:3:7: bad: "x"`},
+		{"no column", &ParseError{"in", 3, 0, "x", errBad}, `in:3: bad: "x"`},
+		{"no line", &ParseError{"in", 0, 7, "x", errBad}, `in: bad: "x"`},
+		{"no text", &ParseError{"in", 3, 7, "", errBad}, `in:3:7: bad`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.err.Error(); got != test.want {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+
+	_, numErr := strconv.Atoi("x")
+	err := NewParseError(1, 2, "x", numErr)
+	if !errors.Is(err, strconv.ErrSyntax) {
+		t.Errorf("%v does not wrap strconv.ErrSyntax", err)
+	}
+	if want := `input:1:2: invalid syntax: "x"`; err.Error() != want {
+		t.Errorf("got %q, want %q", err.Error(), want)
+	}
+}
+
+func TestErrorAt(t *testing.T) {
+	piece := "ab cd\nef gh"
+	err := ErrorAt(piece, strings.Index(piece, "gh"), "gh", errors.New("bad"))
+	if err.Line != 2 || err.Column != 4 {
+		t.Errorf("got %d:%d, want 2:4", err.Line, err.Column)
+	}
+	err = ErrorAt(piece, 0, "ab", errors.New("bad"))
+	if err.Line != 1 || err.Column != 1 {
+		t.Errorf("got %d:%d, want 1:1", err.Line, err.Column)
+	}
+}
+
+func TestAtLine(t *testing.T) {
+	if AtLine(nil, 5) != nil {
+		t.Error("AtLine(nil) is not nil")
+	}
+
+	var parseErr *ParseError
+	err := AtLine(NewParseError(2, 3, "x", errors.New("bad")), 5)
+	if !errors.As(err, &parseErr) || parseErr.Line != 6 || parseErr.Column != 3 {
+		t.Errorf("got %v, want line 6, column 3", err)
+	}
+	err = AtLine(NewParseError(0, 3, "x", errors.New("bad")), 5)
+	if !errors.As(err, &parseErr) || parseErr.Line != 5 {
+		t.Errorf("got %v, want line 5", err)
+	}
+	errBad := errors.New("bad")
+	err = AtLine(errBad, 5)
+	if !errors.As(err, &parseErr) || parseErr.Line != 5 ||
+		!errors.Is(err, errBad) {
+		t.Errorf("got %v, want %v at line 5", err, errBad)
+	}
+}
+
+func TestFieldsAt(t *testing.T) {
+	got := FieldsAt("  ab c\tdef ")
+	want := []Field{{"ab", 2}, {"c", 5}, {"def", 7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if got := FieldsAt(" "); len(got) != 0 {
+		t.Errorf("got %v, want no fields", got)
+	}
+
+	got = SplitAt("1, 22, 333", ", ")
+	want = []Field{{"1", 0}, {"22", 3}, {"333", 7}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestSnippet(t *testing.T) {
+	data := []byte("first\r\nbyr:1937 hgt183cm\n")
+	err := &ParseError{Line: 2, Column: 10, Text: "hgt183cm"}
+	want := " 2 | byr:1937 hgt183cm\n   |          ^^^^^^^^\n"
+	if got := err.Snippet(data); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	// Without a column, only the line is shown.
+	err.Column = 0
+	if got, want := err.Snippet(data), " 2 | byr:1937 hgt183cm\n"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	err.Line = 5
+	if got := err.Snippet(data); got != "" {
+		t.Errorf("got %q for a line past the end", got)
+	}
+}
@@ -0,0 +1,295 @@
+package util
+
+import (
+	"errors"
+	"strings"
+)
+
+// Point is a position in a Grid, or a step between positions.
+type Point struct {
+	Row int
+	Col int
+}
+
+func (p Point) Add(q Point) Point {
+	return Point{p.Row + q.Row, p.Col + q.Col}
+}
+
+// Steps to the neighbors of a point.
+var (
+	Up    = Point{-1, 0}
+	Down  = Point{1, 0}
+	Left  = Point{0, -1}
+	Right = Point{0, 1}
+
+	// The four orthogonal neighbors, clockwise from Up.
+	Directions4 = []Point{Up, Right, Down, Left}
+
+	// All eight neighbors including diagonals, clockwise from Up.
+	Directions8 = []Point{Up, {-1, 1}, Right, {1, 1}, Down, {1, -1}, Left,
+		{-1, -1}}
+)
+
+// Wrap says what lies beyond the edges of a grid.
+type Wrap int
+
+const (
+	// Nothing: points outside the grid are out of bounds.
+	NoWrap Wrap = iota
+	// The grid repeats to the left and right.
+	WrapCols
+	// The grid repeats above and below.
+	WrapRows
+	// The grid repeats in every direction, like a torus.
+	WrapBoth
+)
+
+// Grid is a rectangle of cells, stored by row.
+type Grid[T any] struct {
+	rows  int
+	cols  int
+	cells []T
+
+	// What lies beyond the edges; see At.
+	Wrap Wrap
+}
+
+func NewGrid[T any](rows int, cols int) *Grid[T] {
+	return &Grid[T]{rows: rows, cols: cols, cells: make([]T, rows*cols)}
+}
+
+// ParseGrid makes a grid from lines of text, one cell per byte.
+//
+// Every line must be as long as the first. Errors, including those from cell,
+// are reported as a ParseError at the offending line and column.
+func ParseGrid[T any](lines []string, cell func(c byte) (T, error)) (
+	*Grid[T], error) {
+	cols := 0
+	if len(lines) > 0 {
+		cols = len(lines[0])
+	}
+	g := NewGrid[T](len(lines), cols)
+	for row, line := range lines {
+		if len(line) != cols {
+			return nil, ParseErrorf(row+1, 1, line,
+				"expected %d columns, found %d", cols, len(line))
+		}
+		for col := 0; col < cols; col++ {
+			value, err := cell(line[col])
+			if err != nil {
+				return nil, NewParseError(row+1, col+1, line[col:col+1], err)
+			}
+			g.cells[row*cols+col] = value
+		}
+	}
+	return g, nil
+}
+
+// ParseBytes makes a grid of the bytes in lines of text; see ParseGrid.
+func ParseBytes(lines []string) (*Grid[byte], error) {
+	return ParseGrid(lines, func(c byte) (byte, error) { return c, nil })
+}
+
+// ByteSet returns a function for ParseGrid which accepts only the given
+// bytes.
+func ByteSet(valid string) func(c byte) (byte, error) {
+	return func(c byte) (byte, error) {
+		if strings.IndexByte(valid, c) < 0 {
+			return c, errors.New("invalid cell")
+		}
+		return c, nil
+	}
+}
+
+func (g *Grid[T]) Rows() int { return g.rows }
+func (g *Grid[T]) Cols() int { return g.cols }
+
+// Cells returns the cells of every row, in order.
+func (g *Grid[T]) Cells() []T { return g.cells }
+
+// Row returns the cells of a row. Changing them changes the grid.
+func (g *Grid[T]) Row(row int) []T {
+	return g.cells[row*g.cols : (row+1)*g.cols]
+}
+
+// Find the index of a point after wrapping, or -1 if it is out of bounds.
+func (g *Grid[T]) index(p Point) int {
+	if len(g.cells) == 0 {
+		return -1
+	}
+	if g.Wrap == WrapRows || g.Wrap == WrapBoth {
+		p.Row = Rotate(0, p.Row, g.rows)
+	}
+	if g.Wrap == WrapCols || g.Wrap == WrapBoth {
+		p.Col = Rotate(0, p.Col, g.cols)
+	}
+	if p.Row < 0 || p.Row >= g.rows || p.Col < 0 || p.Col >= g.cols {
+		return -1
+	}
+	return p.Row*g.cols + p.Col
+}
+
+// In reports whether a point is in the grid, after wrapping.
+func (g *Grid[T]) In(p Point) bool {
+	return g.index(p) >= 0
+}
+
+// At returns the cell at a point and true, or the zero value and false if the
+// point is out of bounds.
+func (g *Grid[T]) At(p Point) (T, bool) {
+	if index := g.index(p); index >= 0 {
+		return g.cells[index], true
+	}
+	var zero T
+	return zero, false
+}
+
+// Get returns the cell at a point, or the zero value if it is out of bounds.
+func (g *Grid[T]) Get(p Point) T {
+	value, _ := g.At(p)
+	return value
+}
+
+// Set the cell at a point. Returns false if the point is out of bounds.
+func (g *Grid[T]) Set(p Point, value T) bool {
+	index := g.index(p)
+	if index >= 0 {
+		g.cells[index] = value
+	}
+	return index >= 0
+}
+
+// Each calls f for every cell, by row.
+func (g *Grid[T]) Each(f func(p Point, value T)) {
+	for index, value := range g.cells {
+		f(Point{index / g.cols, index % g.cols}, value)
+	}
+}
+
+// Count the cells for which f is true.
+func (g *Grid[T]) Count(f func(value T) bool) (n int) {
+	for _, value := range g.cells {
+		if f(value) {
+			n++
+		}
+	}
+	return n
+}
+
+// Neighbors calls f for the neighbor of p in each direction, like Directions4
+// or Directions8, which is in bounds. The point passed to f is not wrapped.
+func (g *Grid[T]) Neighbors(p Point, directions []Point,
+	f func(p Point, value T)) {
+	for _, step := range directions {
+		q := p.Add(step)
+		if value, ok := g.At(q); ok {
+			f(q, value)
+		}
+	}
+}
+
+// LineOfSight looks from p along a direction for the first cell which is not
+// see-through, and returns its point and value. Returns false if there is no
+// such cell before the edge of the grid, or, when the grid wraps, before the
+// line of sight repeats.
+func (g *Grid[T]) LineOfSight(p Point, step Point,
+	seeThrough func(value T) bool) (Point, T, bool) {
+	for n := 0; n < g.rows*g.cols; n++ {
+		p = p.Add(step)
+		value, ok := g.At(p)
+		if !ok {
+			break
+		}
+		if !seeThrough(value) {
+			return p, value, true
+		}
+	}
+	var zero T
+	return p, zero, false
+}
+
+func (g *Grid[T]) Clone() *Grid[T] {
+	clone := *g
+	clone.cells = make([]T, len(g.cells))
+	copy(clone.cells, g.cells)
+	return &clone
+}
+
+// Make a grid of the given size whose cell at each point comes from the
+// point of g given by from.
+func (g *Grid[T]) transform(rows int, cols int,
+	from func(p Point) Point) *Grid[T] {
+	t := NewGrid[T](rows, cols)
+	t.Wrap = g.Wrap
+	for index := range t.cells {
+		source := from(Point{index / cols, index % cols})
+		t.cells[index] = g.cells[source.Row*g.cols+source.Col]
+	}
+	return t
+}
+
+// Slice returns a copy of the rows from top up to bottom and the columns from
+// left up to right, which must be within the grid.
+func (g *Grid[T]) Slice(top int, left int, bottom int, right int) *Grid[T] {
+	return g.transform(bottom-top, right-left, func(p Point) Point {
+		return Point{p.Row + top, p.Col + left}
+	})
+}
+
+// Rotate returns a copy of the grid turned 90 degrees clockwise.
+func (g *Grid[T]) Rotate() *Grid[T] {
+	return g.transform(g.cols, g.rows, func(p Point) Point {
+		return Point{g.rows - 1 - p.Col, p.Row}
+	})
+}
+
+// FlipH returns a copy of the grid mirrored left to right.
+func (g *Grid[T]) FlipH() *Grid[T] {
+	return g.transform(g.rows, g.cols, func(p Point) Point {
+		return Point{p.Row, g.cols - 1 - p.Col}
+	})
+}
+
+// FlipV returns a copy of the grid mirrored top to bottom.
+func (g *Grid[T]) FlipV() *Grid[T] {
+	return g.transform(g.rows, g.cols, func(p Point) Point {
+		return Point{g.rows - 1 - p.Row, p.Col}
+	})
+}
+
+// Transpose returns a copy of the grid mirrored along its main diagonal.
+func (g *Grid[T]) Transpose() *Grid[T] {
+	return g.transform(g.cols, g.rows, func(p Point) Point {
+		return Point{p.Col, p.Row}
+	})
+}
+
+// Orientations returns the eight rotations and reflections of the grid,
+// starting with a copy of the grid itself.
+func (g *Grid[T]) Orientations() []*Grid[T] {
+	orientations := make([]*Grid[T], 0, 8)
+	rotated := g.Clone()
+	for n := 0; n < 4; n++ {
+		orientations = append(orientations, rotated, rotated.FlipH())
+		rotated = rotated.Rotate()
+	}
+	return orientations
+}
+
+// Render the grid as lines of text, one byte per cell.
+func (g *Grid[T]) Render(cell func(value T) byte) string {
+	var text strings.Builder
+	text.Grow(g.rows * (g.cols + 1))
+	for index, value := range g.cells {
+		if index > 0 && index%g.cols == 0 {
+			text.WriteByte('\n')
+		}
+		text.WriteByte(cell(value))
+	}
+	return text.String()
+}
+
+// RenderBytes renders a grid of bytes as lines of text; see Grid.Render.
+func RenderBytes(g *Grid[byte]) string {
+	return g.Render(func(c byte) byte { return c })
+}
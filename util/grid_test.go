@@ -0,0 +1,179 @@
+package util
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseGrid(t *testing.T) {
+	g, err := ParseBytes([]string{"ab", "cd", "ef"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if g.Rows() != 3 || g.Cols() != 2 {
+		t.Errorf("got %d x %d, want 3 x 2", g.Rows(), g.Cols())
+	}
+	if got := RenderBytes(g); got != "ab\ncd\nef" {
+		t.Errorf("got %q", got)
+	}
+
+	var parseErr *ParseError
+	_, err = ParseBytes([]string{"ab", "c"})
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 {
+		t.Errorf("got %v, want an error on line 2", err)
+	}
+	_, err = ParseGrid([]string{"..", ".x"}, ByteSet(".#"))
+	if !errors.As(err, &parseErr) || parseErr.Line != 2 ||
+		parseErr.Column != 2 {
+		t.Errorf("got %v, want an error at 2:2", err)
+	}
+}
+
+func TestGridAt(t *testing.T) {
+	g, _ := ParseBytes([]string{"abc", "def"})
+	tests := []struct {
+		wrap  Wrap
+		point Point
+		want  byte
+		ok    bool
+	}{
+		{NoWrap, Point{1, 2}, 'f', true},
+		{NoWrap, Point{2, 0}, 0, false},
+		{NoWrap, Point{0, -1}, 0, false},
+		{WrapCols, Point{1, 5}, 'f', true},
+		{WrapCols, Point{0, -1}, 'c', true},
+		{WrapCols, Point{2, 0}, 0, false},
+		{WrapRows, Point{-1, 0}, 'd', true},
+		{WrapRows, Point{0, 3}, 0, false},
+		{WrapBoth, Point{-3, -4}, 'f', true},
+	}
+	for _, test := range tests {
+		g.Wrap = test.wrap
+		got, ok := g.At(test.point)
+		if got != test.want || ok != test.ok {
+			t.Errorf("wrap %d: At(%v) = %q, %v, want %q, %v", test.wrap,
+				test.point, got, ok, test.want, test.ok)
+		}
+	}
+
+	g.Wrap = NoWrap
+	if g.Set(Point{2, 2}, 'x') {
+		t.Error("Set out of bounds succeeded")
+	}
+	if !g.Set(Point{0, 1}, 'x') || g.Get(Point{0, 1}) != 'x' {
+		t.Error("Set in bounds failed")
+	}
+}
+
+func TestGridNeighbors(t *testing.T) {
+	g, _ := ParseBytes([]string{
+		"#.#",
+		"...",
+		"#.#",
+	})
+	count := func(p Point, directions []Point) (n int) {
+		g.Neighbors(p, directions, func(_ Point, c byte) {
+			if c == '#' {
+				n++
+			}
+		})
+		return n
+	}
+	if n := count(Point{1, 1}, Directions4); n != 0 {
+		t.Errorf("got %d orthogonal neighbors, want 0", n)
+	}
+	if n := count(Point{1, 1}, Directions8); n != 4 {
+		t.Errorf("got %d neighbors, want 4", n)
+	}
+	if n := count(Point{0, 1}, Directions8); n != 2 {
+		t.Errorf("got %d neighbors on the edge, want 2", n)
+	}
+
+	floor := func(c byte) bool { return c == '.' }
+	if p, c, ok := g.LineOfSight(Point{0, 0}, Right, floor); !ok ||
+		p != (Point{0, 2}) || c != '#' {
+		t.Errorf("LineOfSight right = %v, %q, %v", p, c, ok)
+	}
+	if _, _, ok := g.LineOfSight(Point{1, 0}, Right, floor); ok {
+		t.Error("LineOfSight saw past the edge")
+	}
+	g.Wrap = WrapBoth
+	if _, _, ok := g.LineOfSight(Point{1, 0}, Right, floor); ok {
+		t.Error("LineOfSight found something in an empty wrapped row")
+	}
+}
+
+func TestGridTransforms(t *testing.T) {
+	g, _ := ParseBytes([]string{
+		"abc",
+		"def",
+	})
+	tests := []struct {
+		name string
+		got  *Grid[byte]
+		want string
+	}{
+		{"Rotate", g.Rotate(), "da\neb\nfc"},
+		{"FlipH", g.FlipH(), "cba\nfed"},
+		{"FlipV", g.FlipV(), "def\nabc"},
+		{"Transpose", g.Transpose(), "ad\nbe\ncf"},
+		{"Rotate x4", g.Rotate().Rotate().Rotate().Rotate(), "abc\ndef"},
+		{"Slice", g.Slice(0, 1, 2, 3), "bc\nef"},
+		{"Slice empty", g.Slice(1, 1, 1, 3), ""},
+	}
+	for _, test := range tests {
+		if got := RenderBytes(test.got); got != test.want {
+			t.Errorf("%s: got %q, want %q", test.name, got, test.want)
+		}
+	}
+
+	// Every orientation is different, and one of them is the transpose.
+	seen := make(map[string]bool, 8)
+	for _, o := range g.Orientations() {
+		seen[RenderBytes(o)] = true
+	}
+	if len(seen) != 8 || !seen[RenderBytes(g.Transpose())] {
+		t.Errorf("got orientations %v", seen)
+	}
+
+	clone := g.Clone()
+	clone.Set(Point{0, 0}, 'x')
+	if !reflect.DeepEqual(g.Row(0), []byte("abc")) {
+		t.Errorf("changing a clone changed the grid to %q", RenderBytes(g))
+	}
+}
+
+func FuzzParseGrid(f *testing.F) {
+	for _, seed := range []string{"", "..#\n#..", "..\n.", "..\n.x", "#"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		lines := strings.Split(data, "\n")
+		g, err := ParseGrid(lines, ByteSet(".#"))
+		if err != nil {
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) || parseErr.Line < 1 ||
+				parseErr.Line > len(lines) {
+				t.Errorf("got %v, want a ParseError within %d lines", err,
+					len(lines))
+			}
+			return
+		}
+		if g.Rows() != len(lines) || len(g.Cells()) != g.Rows()*g.Cols() {
+			t.Errorf("got %d x %d with %d cells from %d lines", g.Rows(),
+				g.Cols(), len(g.Cells()), len(lines))
+		}
+		g.Each(func(p Point, value byte) {
+			if value != lines[p.Row][p.Col] {
+				t.Errorf("%v is %q, want %q", p, value, lines[p.Row][p.Col])
+			}
+			g.Neighbors(p, Directions8, func(q Point, value byte) {
+				if !g.In(q) {
+					t.Errorf("neighbor %v of %v is outside the grid", q, p)
+				}
+			})
+		})
+	})
+}
@@ -0,0 +1,87 @@
+package util
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+// A group of lines from the input, and the line number where it starts.
+type LineGroup struct {
+	Text string
+	Line int
+}
+
+// LineGroupScanner reads groups of lines separated by blank lines one group at
+// a time, like a bufio.Scanner, so the whole input need not be in memory.
+//
+// Lines may end with "\n" or "\r\n", and lines of only whitespace count as
+// blank. The lines of a group are joined with "\n", and the last has no line
+// ending.
+type LineGroupScanner struct {
+	lines *bufio.Scanner
+	text  strings.Builder
+	group LineGroup
+
+	// Number of lines read, and of groups found.
+	line  int
+	count int
+}
+
+func NewLineGroupScanner(input io.Reader) *LineGroupScanner {
+	return &LineGroupScanner{lines: bufio.NewScanner(input)}
+}
+
+// Scan advances to the next group, which is then available through Group.
+// Returns false at the end of the input or on an error; see Err.
+func (s *LineGroupScanner) Scan() bool {
+	s.group = LineGroup{}
+	s.text.Reset()
+	for s.lines.Scan() {
+		s.line++
+		line := s.lines.Text()
+		if strings.TrimSpace(line) == "" {
+			if s.text.Len() > 0 {
+				break
+			}
+			continue
+		}
+		if s.text.Len() == 0 {
+			s.group.Line = s.line
+		} else {
+			s.text.WriteByte('\n')
+		}
+		s.text.WriteString(line)
+	}
+	if s.text.Len() == 0 || s.lines.Err() != nil {
+		return false
+	}
+	s.group.Text = s.text.String()
+	s.count++
+	return true
+}
+
+// The group found by the last call to Scan.
+func (s *LineGroupScanner) Group() LineGroup {
+	return s.group
+}
+
+// The text of the group found by the last call to Scan.
+func (s *LineGroupScanner) Text() string {
+	return s.group.Text
+}
+
+// The line number where the group found by the last call to Scan starts.
+func (s *LineGroupScanner) Line() int {
+	return s.group.Line
+}
+
+// Number of groups found so far, so 1 during the first group.
+func (s *LineGroupScanner) Count() int {
+	return s.count
+}
+
+// The first error reading the input, if any.
+func (s *LineGroupScanner) Err() error {
+	return s.lines.Err()
+}
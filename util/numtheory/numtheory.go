@@ -0,0 +1,250 @@
+// Package numtheory has integer arithmetic for puzzles about divisibility and
+// remainders: powers, greatest common divisors, modular inverses and the
+// Chinese remainder theorem.
+//
+// The int64 functions which could overflow say so with ErrOverflow, and
+// those ending in Big work on math/big integers of any size.
+package numtheory
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"math/bits"
+)
+
+var (
+	// ErrOverflow is returned when a result does not fit in an int64.
+	ErrOverflow = errors.New("integer overflow")
+
+	// ErrNoSolution is returned by CRT when the congruences contradict each
+	// other.
+	ErrNoSolution = errors.New("no solution")
+)
+
+func abs(a int64) int64 {
+	if a < 0 {
+		return -a
+	}
+	return a
+}
+
+// Mod returns a modulo m in [0, |m|), unlike the % operator, which keeps the
+// sign of a.
+func Mod(a int64, m int64) int64 {
+	r := a % m
+	if r < 0 {
+		r += abs(m)
+	}
+	return r
+}
+
+// AddChecked returns a + b, or ErrOverflow.
+func AddChecked(a int64, b int64) (int64, error) {
+	sum := a + b
+	if (b > 0 && sum < a) || (b < 0 && sum > a) {
+		return 0, ErrOverflow
+	}
+	return sum, nil
+}
+
+// MulChecked returns a * b, or ErrOverflow.
+func MulChecked(a int64, b int64) (int64, error) {
+	if a == 0 || b == 0 {
+		return 0, nil
+	}
+	product := a * b
+	if product/b != a || (a == -1 && b == math.MinInt64) ||
+		(b == -1 && a == math.MinInt64) {
+		return 0, ErrOverflow
+	}
+	return product, nil
+}
+
+// MulMod returns a * b modulo m, in [0, m), without overflowing. m must be
+// positive.
+func MulMod(a int64, b int64, m int64) int64 {
+	hi, lo := bits.Mul64(uint64(Mod(a, m)), uint64(Mod(b, m)))
+	return int64(bits.Rem64(hi, lo, uint64(m)))
+}
+
+// Pow returns base to the power exp, which must not be negative. The result
+// wraps around on overflow; see PowChecked.
+func Pow(base int64, exp int) int64 {
+	if exp < 0 {
+		panic(fmt.Sprintf("numtheory: negative exponent %d", exp))
+	}
+	result := int64(1)
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 != 0 {
+			result *= base
+		}
+		base *= base
+	}
+	return result
+}
+
+// PowChecked returns base to the power exp, or ErrOverflow.
+func PowChecked(base int64, exp int) (int64, error) {
+	if exp < 0 {
+		return 0, fmt.Errorf("negative exponent %d", exp)
+	}
+	result := int64(1)
+	var err error
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 != 0 {
+			if result, err = MulChecked(result, base); err != nil {
+				return 0, err
+			}
+		}
+		// Unless base is 0, the result is at least base squared, so this
+		// only overflows if the result would.
+		if exp > 1 {
+			if base, err = MulChecked(base, base); err != nil {
+				return 0, err
+			}
+		}
+	}
+	return result, nil
+}
+
+// ModPow returns base to the power exp modulo m, in [0, m). exp must not be
+// negative and m must be positive.
+func ModPow(base int64, exp int64, m int64) int64 {
+	if exp < 0 {
+		panic(fmt.Sprintf("numtheory: negative exponent %d", exp))
+	}
+	result := Mod(1, m)
+	base = Mod(base, m)
+	for ; exp > 0; exp >>= 1 {
+		if exp&1 != 0 {
+			result = MulMod(result, base, m)
+		}
+		base = MulMod(base, base, m)
+	}
+	return result
+}
+
+// GCD returns the greatest common divisor of a and b, which is never
+// negative. GCD(0, 0) is 0.
+func GCD(a int64, b int64) int64 {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return abs(a)
+}
+
+// LCM returns the least common multiple of a and b, which is never negative,
+// or 0 if either is 0. The result wraps around on overflow.
+func LCM(a int64, b int64) int64 {
+	if a == 0 || b == 0 {
+		return 0
+	}
+	return abs(a / GCD(a, b) * b)
+}
+
+// ExtendedGCD returns the greatest common divisor g of a and b along with
+// Bézout coefficients x and y such that a*x + b*y = g.
+func ExtendedGCD(a int64, b int64) (g int64, x int64, y int64) {
+	oldR, r := a, b
+	oldX, x := int64(1), int64(0)
+	oldY, y := int64(0), int64(1)
+	for r != 0 {
+		q := oldR / r
+		oldR, r = r, oldR-q*r
+		oldX, x = x, oldX-q*x
+		oldY, y = y, oldY-q*y
+	}
+	if oldR < 0 {
+		return -oldR, -oldX, -oldY
+	}
+	return oldR, oldX, oldY
+}
+
+// ModInverse returns x in [0, m) such that a*x = 1 modulo m, or an error if a
+// and m are not coprime. m must be positive.
+func ModInverse(a int64, m int64) (int64, error) {
+	g, x, _ := ExtendedGCD(Mod(a, m), m)
+	if g != 1 {
+		return 0, fmt.Errorf("%d has no inverse modulo %d", a, m)
+	}
+	return Mod(x, m), nil
+}
+
+// CRT solves the congruences x = residues[i] modulo moduli[i] with the
+// Chinese remainder theorem. The moduli must be positive, but need not be
+// coprime.
+//
+// Returns the least non-negative solution x and the least common multiple of
+// the moduli, so the solutions are x + n*lcm. Returns ErrNoSolution if the
+// congruences contradict each other, or ErrOverflow if the least common
+// multiple does not fit in an int64; see CRTBig.
+func CRT(residues []int64, moduli []int64) (x int64, lcm int64, err error) {
+	if len(residues) != len(moduli) {
+		return 0, 0, fmt.Errorf("%d residues for %d moduli", len(residues),
+			len(moduli))
+	}
+	x, lcm = 0, 1
+	for index, m := range moduli {
+		if m <= 0 {
+			return 0, 0, fmt.Errorf("invalid modulus %d", m)
+		}
+		r := Mod(residues[index], m)
+
+		// Find x + lcm*k = r modulo m. With g = gcd(lcm, m), this has a
+		// solution only if g divides r - x; then
+		// k = (r - x)/g * inverse(lcm/g) modulo m/g.
+		g, inverse, _ := ExtendedGCD(lcm, m)
+		diff := r - Mod(x, m)
+		if diff%g != 0 {
+			return 0, 0, fmt.Errorf("%w: x = %d modulo %d contradicts "+
+				"x = %d modulo %d", ErrNoSolution, r, m, x, lcm)
+		}
+		step := m / g
+		k := MulMod(diff/g, inverse, step)
+
+		next, err := MulChecked(lcm, step)
+		if err != nil {
+			return 0, 0, err
+		}
+		// x + lcm*k < lcm*step, so it fits once the new lcm does.
+		x = Mod(x+MulMod(lcm, k, next), next)
+		lcm = next
+	}
+	return x, lcm, nil
+}
+
+// CRTBig is CRT for integers of any size.
+func CRTBig(residues []*big.Int, moduli []*big.Int) (x *big.Int,
+	lcm *big.Int, err error) {
+	if len(residues) != len(moduli) {
+		return nil, nil, fmt.Errorf("%d residues for %d moduli",
+			len(residues), len(moduli))
+	}
+	x, lcm = big.NewInt(0), big.NewInt(1)
+	g, inverse := new(big.Int), new(big.Int)
+	r, diff, step, k := new(big.Int), new(big.Int), new(big.Int), new(big.Int)
+	for index, m := range moduli {
+		if m.Sign() <= 0 {
+			return nil, nil, fmt.Errorf("invalid modulus %v", m)
+		}
+		r.Mod(residues[index], m)
+
+		g.GCD(inverse, nil, lcm, m)
+		diff.Sub(r, diff.Mod(x, m))
+		if new(big.Int).Rem(diff, g).Sign() != 0 {
+			return nil, nil, fmt.Errorf("%w: x = %v modulo %v contradicts "+
+				"x = %v modulo %v", ErrNoSolution, r, m, x, lcm)
+		}
+		step.Quo(m, g)
+		k.Quo(diff, g)
+		k.Mul(k, inverse)
+		k.Mod(k, step)
+
+		x.Add(x, k.Mul(k, lcm))
+		lcm.Mul(lcm, step)
+		x.Mod(x, lcm)
+	}
+	return x, lcm, nil
+}
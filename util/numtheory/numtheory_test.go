@@ -0,0 +1,206 @@
+package numtheory
+
+import (
+	"errors"
+	"math"
+	"math/big"
+	"testing"
+)
+
+func TestMod(t *testing.T) {
+	tests := []struct{ a, m, want int64 }{
+		{7, 3, 1},
+		{-7, 3, 2},
+		{-6, 3, 0},
+		{7, -3, 1},
+	}
+	for _, test := range tests {
+		if got := Mod(test.a, test.m); got != test.want {
+			t.Errorf("Mod(%d, %d) = %d, want %d", test.a, test.m, got, test.want)
+		}
+	}
+}
+
+func TestPow(t *testing.T) {
+	tests := []struct {
+		base int64
+		exp  int
+		want int64
+	}{
+		{2, 0, 1},
+		{2, 10, 1024},
+		{-3, 3, -27},
+		{10, 18, 1e18},
+		{-2, 63, math.MinInt64},
+		{0, 5, 0},
+	}
+	for _, test := range tests {
+		if got := Pow(test.base, test.exp); got != test.want {
+			t.Errorf("Pow(%d, %d) = %d, want %d", test.base, test.exp, got,
+				test.want)
+		}
+		got, err := PowChecked(test.base, test.exp)
+		if err != nil || got != test.want {
+			t.Errorf("PowChecked(%d, %d) = %d, %v, want %d", test.base,
+				test.exp, got, err, test.want)
+		}
+	}
+	for _, exp := range []int{19, 64} {
+		if _, err := PowChecked(10, exp); !errors.Is(err, ErrOverflow) {
+			t.Errorf("PowChecked(10, %d): got %v, want ErrOverflow", exp, err)
+		}
+	}
+	if _, err := PowChecked(2, 63); !errors.Is(err, ErrOverflow) {
+		t.Errorf("PowChecked(2, 63): got %v, want ErrOverflow", err)
+	}
+}
+
+func TestChecked(t *testing.T) {
+	if _, err := AddChecked(math.MaxInt64, 1); !errors.Is(err, ErrOverflow) {
+		t.Error("AddChecked(MaxInt64, 1) did not overflow")
+	}
+	if _, err := AddChecked(math.MinInt64, -1); !errors.Is(err, ErrOverflow) {
+		t.Error("AddChecked(MinInt64, -1) did not overflow")
+	}
+	if sum, err := AddChecked(-5, 3); err != nil || sum != -2 {
+		t.Errorf("AddChecked(-5, 3) = %d, %v", sum, err)
+	}
+	if _, err := MulChecked(math.MaxInt64/2+1, 2); !errors.Is(err, ErrOverflow) {
+		t.Error("MulChecked(MaxInt64/2+1, 2) did not overflow")
+	}
+	if _, err := MulChecked(-1, math.MinInt64); !errors.Is(err, ErrOverflow) {
+		t.Error("MulChecked(-1, MinInt64) did not overflow")
+	}
+	if product, err := MulChecked(-4, 5); err != nil || product != -20 {
+		t.Errorf("MulChecked(-4, 5) = %d, %v", product, err)
+	}
+}
+
+func TestModPow(t *testing.T) {
+	tests := []struct{ base, exp, m, want int64 }{
+		{4, 13, 497, 445},
+		{2, 0, 7, 1},
+		{-2, 3, 7, 6},
+		{5, 3, 1, 0},
+		// The square of the modulus does not fit in an int64.
+		{math.MaxInt64 - 1, 2, math.MaxInt64, 1},
+	}
+	for _, test := range tests {
+		if got := ModPow(test.base, test.exp, test.m); got != test.want {
+			t.Errorf("ModPow(%d, %d, %d) = %d, want %d", test.base, test.exp,
+				test.m, got, test.want)
+		}
+	}
+}
+
+func TestGCD(t *testing.T) {
+	tests := []struct{ a, b, gcd, lcm int64 }{
+		{12, 18, 6, 36},
+		{-12, 18, 6, 36},
+		{7, 13, 1, 91},
+		{0, 5, 5, 0},
+		{0, 0, 0, 0},
+	}
+	for _, test := range tests {
+		if got := GCD(test.a, test.b); got != test.gcd {
+			t.Errorf("GCD(%d, %d) = %d, want %d", test.a, test.b, got, test.gcd)
+		}
+		if got := LCM(test.a, test.b); got != test.lcm {
+			t.Errorf("LCM(%d, %d) = %d, want %d", test.a, test.b, got, test.lcm)
+		}
+		g, x, y := ExtendedGCD(test.a, test.b)
+		if g != test.gcd || test.a*x+test.b*y != g {
+			t.Errorf("ExtendedGCD(%d, %d) = %d, %d, %d", test.a, test.b, g, x, y)
+		}
+	}
+}
+
+func TestModInverse(t *testing.T) {
+	if x, err := ModInverse(3, 11); err != nil || x != 4 {
+		t.Errorf("ModInverse(3, 11) = %d, %v, want 4", x, err)
+	}
+	if x, err := ModInverse(-3, 11); err != nil || x != 7 {
+		t.Errorf("ModInverse(-3, 11) = %d, %v, want 7", x, err)
+	}
+	if _, err := ModInverse(4, 10); err == nil {
+		t.Error("ModInverse(4, 10) found an inverse")
+	}
+}
+
+func TestCRT(t *testing.T) {
+	tests := []struct {
+		name     string
+		residues []int64
+		moduli   []int64
+		x, lcm   int64
+	}{
+		{"coprime", []int64{2, 3, 2}, []int64{3, 5, 7}, 23, 105},
+		{"negative residues", []int64{-1, -2}, []int64{3, 5}, 8, 15},
+		{"not coprime", []int64{2, 4}, []int64{6, 8}, 20, 24},
+		{"none", nil, nil, 0, 1},
+		// Day 13's example: T = -offset modulo each bus.
+		{"day 13", []int64{0, -1, -4, -6, -7}, []int64{7, 13, 59, 31, 19},
+			1068781, 7 * 13 * 59 * 31 * 19},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			x, lcm, err := CRT(test.residues, test.moduli)
+			if err != nil || x != test.x || lcm != test.lcm {
+				t.Errorf("CRT() = %d, %d, %v, want %d, %d", x, lcm, err, test.x,
+					test.lcm)
+			}
+
+			residues := make([]*big.Int, len(test.residues))
+			moduli := make([]*big.Int, len(test.moduli))
+			for index := range test.residues {
+				residues[index] = big.NewInt(test.residues[index])
+				moduli[index] = big.NewInt(test.moduli[index])
+			}
+			bigX, bigLCM, err := CRTBig(residues, moduli)
+			if err != nil || bigX.Int64() != test.x ||
+				bigLCM.Int64() != test.lcm {
+				t.Errorf("CRTBig() = %v, %v, %v, want %d, %d", bigX, bigLCM,
+					err, test.x, test.lcm)
+			}
+		})
+	}
+
+	if _, _, err := CRT([]int64{1, 2}, []int64{4, 6}); !errors.Is(err,
+		ErrNoSolution) {
+		t.Errorf("got %v, want ErrNoSolution", err)
+	}
+	_, _, err := CRTBig([]*big.Int{big.NewInt(1), big.NewInt(2)},
+		[]*big.Int{big.NewInt(4), big.NewInt(6)})
+	if !errors.Is(err, ErrNoSolution) {
+		t.Errorf("CRTBig: got %v, want ErrNoSolution", err)
+	}
+	if _, _, err := CRT([]int64{1}, []int64{0}); err == nil {
+		t.Error("CRT accepted a zero modulus")
+	}
+
+	// The product of these primes does not fit in an int64, but does in a
+	// big.Int.
+	moduli := []int64{1000000007, 1000000009, 998244353}
+	residues := []int64{1, 2, 3}
+	if _, _, err := CRT(residues, moduli); !errors.Is(err, ErrOverflow) {
+		t.Errorf("got %v, want ErrOverflow", err)
+	}
+	bigResidues := make([]*big.Int, len(moduli))
+	bigModuli := make([]*big.Int, len(moduli))
+	for index := range moduli {
+		bigResidues[index] = big.NewInt(residues[index])
+		bigModuli[index] = big.NewInt(moduli[index])
+	}
+	x, lcm, err := CRTBig(bigResidues, bigModuli)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for index, m := range bigModuli {
+		if new(big.Int).Mod(x, m).Cmp(bigResidues[index]) != 0 {
+			t.Errorf("%v is not %v modulo %v", x, bigResidues[index], m)
+		}
+	}
+	if x.Cmp(lcm) >= 0 {
+		t.Errorf("%v is not less than %v", x, lcm)
+	}
+}
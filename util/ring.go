@@ -0,0 +1,219 @@
+package util
+
+// RingBuffer is a queue of up to Cap() values, like a ring.Ring, but using a
+// slice rather than a linked list.
+//
+// Push adds a value at the end, overwriting the first value once the buffer
+// is full; Append grows the buffer instead.
+type RingBuffer[T any] struct {
+	data []T
+
+	// Index of the head, i.e. the first (least recently pushed) value.
+	head int
+
+	// Number of values; the tail, i.e. the last (most recently pushed) value,
+	// is at (head + size - 1) % Cap().
+	size int
+}
+
+func NewRingBuffer[T any](capacity int) *RingBuffer[T] {
+	return &RingBuffer[T]{data: make([]T, capacity)}
+}
+
+// Number of values in the buffer.
+func (r *RingBuffer[T]) Len() int {
+	return r.size
+}
+
+// Number of values the buffer holds before Push overwrites them.
+func (r *RingBuffer[T]) Cap() int {
+	return len(r.data)
+}
+
+func (r *RingBuffer[T]) Full() bool {
+	return r.size == len(r.data)
+}
+
+// Index into data of the n-th value, from 0 to Len()-1.
+func (r *RingBuffer[T]) index(n int) int {
+	index := r.head + n
+	if index >= len(r.data) {
+		index -= len(r.data)
+	}
+	return index
+}
+
+// Index into data of the n-th value counting from the head (n >= 0) or back
+// from the tail (n < 0), modulo Len(). Panics if the buffer is empty.
+func (r *RingBuffer[T]) rotated(n int) int {
+	if r.size == 0 {
+		panic("util: empty RingBuffer")
+	}
+	return r.index(Rotate(0, n, r.size))
+}
+
+// Push a value at the end.
+//
+// If the buffer was full, the first value is overwritten and returned along
+// with true.
+func (r *RingBuffer[T]) Push(value T) (overwritten T, ok bool) {
+	if len(r.data) == 0 {
+		return value, true
+	}
+	if r.Full() {
+		overwritten, ok = r.data[r.head], true
+		r.data[r.head] = value
+		r.head = r.index(1)
+		return overwritten, ok
+	}
+	r.data[r.index(r.size)] = value
+	r.size++
+	return overwritten, false
+}
+
+// Append a value at the end, growing the buffer if it is full.
+func (r *RingBuffer[T]) Append(value T) {
+	if r.Full() {
+		r.Grow(1)
+	}
+	r.Push(value)
+}
+
+// Grow the capacity of the buffer, if necessary, to hold n more values.
+func (r *RingBuffer[T]) Grow(n int) {
+	if r.size+n <= len(r.data) {
+		return
+	}
+	capacity := 2 * len(r.data)
+	if capacity < r.size+n {
+		capacity = r.size + n
+	}
+	data := make([]T, capacity)
+	r.Do(func(n int, value T) {
+		data[n] = value
+	})
+	r.data = data
+	r.head = 0
+}
+
+// Pop the last (most recently pushed) value. Returns false if the buffer is
+// empty.
+func (r *RingBuffer[T]) Pop() (value T, ok bool) {
+	if r.size == 0 {
+		return value, false
+	}
+	tail := r.index(r.size - 1)
+	value = r.data[tail]
+	var zero T
+	r.data[tail] = zero
+	r.size--
+	return value, true
+}
+
+// Shift the first (least recently pushed) value off of the buffer. Returns
+// false if the buffer is empty.
+func (r *RingBuffer[T]) Shift() (value T, ok bool) {
+	if r.size == 0 {
+		return value, false
+	}
+	value = r.data[r.head]
+	var zero T
+	r.data[r.head] = zero
+	r.head = r.index(1)
+	r.size--
+	return value, true
+}
+
+// Return the first (least recently pushed) value. Panics if the buffer is
+// empty.
+func (r *RingBuffer[T]) First() T {
+	return r.data[r.rotated(0)]
+}
+
+// Return the last (most recently pushed) value. Panics if the buffer is
+// empty.
+func (r *RingBuffer[T]) Last() T {
+	return r.data[r.rotated(-1)]
+}
+
+// Get the n-th least recently pushed value, modulo Len().
+//
+// For example, Get(0) == First(),
+// and Get(-1) == Get(Len()-1) == Last().
+func (r *RingBuffer[T]) Get(n int) T {
+	return r.data[r.rotated(n)]
+}
+
+// Get the n-th most recently pushed value, modulo Len().
+//
+// For example, GetLast(0) == Last(),
+// and GetLast(-1) == GetLast(Len()-1) == First().
+func (r *RingBuffer[T]) GetLast(n int) T {
+	return r.data[r.rotated(-1-n)]
+}
+
+// Move the first n values to the end (n >= 0), or the last -n values to the
+// front (n < 0).
+func (r *RingBuffer[T]) Move(n int) *RingBuffer[T] {
+	if r.size == 0 {
+		return r
+	}
+	n = Rotate(0, n, r.size)
+	if r.Full() {
+		r.head = r.index(n)
+		return r
+	}
+	for ; n > 0; n-- {
+		value, _ := r.Shift()
+		r.Push(value)
+	}
+	return r
+}
+
+// Call f on each value from first to last, along with its position.
+func (r *RingBuffer[T]) Do(f func(n int, value T)) {
+	for n := 0; n < r.size; n++ {
+		f(n, r.data[r.index(n)])
+	}
+}
+
+// Call f on each value from first to last so long as f returns true.
+//
+// Returns false if f did.
+func (r *RingBuffer[T]) DoWhile(f func(n int, value T) bool) bool {
+	for n := 0; n < r.size; n++ {
+		if !f(n, r.data[r.index(n)]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Call f on each value from last to first, along with its position counting
+// back from the last, as for GetLast.
+func (r *RingBuffer[T]) DoReverse(f func(n int, value T)) {
+	for n := 0; n < r.size; n++ {
+		f(n, r.data[r.index(r.size-1-n)])
+	}
+}
+
+// Call f on each value from last to first so long as f returns true.
+//
+// Returns false if f did.
+func (r *RingBuffer[T]) DoReverseWhile(f func(n int, value T) bool) bool {
+	for n := 0; n < r.size; n++ {
+		if !f(n, r.data[r.index(r.size-1-n)]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Slice returns a copy of the values from first to last.
+func (r *RingBuffer[T]) Slice() []T {
+	values := make([]T, r.size)
+	r.Do(func(n int, value T) {
+		values[n] = value
+	})
+	return values
+}
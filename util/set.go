@@ -0,0 +1,152 @@
+package util
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// Set is an unordered collection of distinct values.
+//
+// Being a map, a Set may be ranged over, but Values and Do visit the values
+// in sorted order.
+type Set[T comparable] map[T]struct{}
+
+func NewSet[T comparable](values ...T) Set[T] {
+	s := make(Set[T], len(values))
+	s.Add(values...)
+	return s
+}
+
+func (s Set[T]) Add(values ...T) {
+	for _, value := range values {
+		s[value] = struct{}{}
+	}
+}
+
+func (s Set[T]) Remove(values ...T) {
+	for _, value := range values {
+		delete(s, value)
+	}
+}
+
+func (s Set[T]) Has(value T) bool {
+	_, ok := s[value]
+	return ok
+}
+
+func (s Set[T]) Len() int {
+	return len(s)
+}
+
+func (s Set[T]) Clone() Set[T] {
+	clone := make(Set[T], len(s))
+	for value := range s {
+		clone[value] = struct{}{}
+	}
+	return clone
+}
+
+// Union returns a new set of the values in either s or o.
+func (s Set[T]) Union(o Set[T]) Set[T] {
+	union := s.Clone()
+	for value := range o {
+		union[value] = struct{}{}
+	}
+	return union
+}
+
+// Intersection returns a new set of the values in both s and o.
+func (s Set[T]) Intersection(o Set[T]) Set[T] {
+	if len(o) < len(s) {
+		s, o = o, s
+	}
+	intersection := make(Set[T], len(s))
+	for value := range s {
+		if o.Has(value) {
+			intersection[value] = struct{}{}
+		}
+	}
+	return intersection
+}
+
+// Difference returns a new set of the values in s but not in o.
+func (s Set[T]) Difference(o Set[T]) Set[T] {
+	difference := make(Set[T], len(s))
+	for value := range s {
+		if !o.Has(value) {
+			difference[value] = struct{}{}
+		}
+	}
+	return difference
+}
+
+// IsSubset reports whether every value in s is also in o.
+func (s Set[T]) IsSubset(o Set[T]) bool {
+	if len(s) > len(o) {
+		return false
+	}
+	for value := range s {
+		if !o.Has(value) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s Set[T]) Equal(o Set[T]) bool {
+	return len(s) == len(o) && s.IsSubset(o)
+}
+
+// Values returns the values in sorted order: numbers and strings in their
+// natural order, and other values by their formatting with fmt.
+func (s Set[T]) Values() []T {
+	values := make([]T, 0, len(s))
+	for value := range s {
+		values = append(values, value)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		return lessValue(reflect.ValueOf(values[i]), reflect.ValueOf(values[j]))
+	})
+	return values
+}
+
+// Do calls f for each value in sorted order; see Values.
+func (s Set[T]) Do(f func(value T)) {
+	for _, value := range s.Values() {
+		f(value)
+	}
+}
+
+// Formats the set like "{1, 2, 3}", in sorted order.
+func (s Set[T]) String() string {
+	var ss strings.Builder
+	ss.WriteRune('{')
+	for index, value := range s.Values() {
+		if index > 0 {
+			ss.WriteString(", ")
+		}
+		fmt.Fprint(&ss, value)
+	}
+	ss.WriteRune('}')
+	return ss.String()
+}
+
+func lessValue(a reflect.Value, b reflect.Value) bool {
+	if a.Kind() == b.Kind() {
+		switch a.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+			reflect.Int64:
+			return a.Int() < b.Int()
+		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32,
+			reflect.Uint64, reflect.Uintptr:
+			return a.Uint() < b.Uint()
+		case reflect.Float32, reflect.Float64:
+			return a.Float() < b.Float()
+		case reflect.String:
+			return a.String() < b.String()
+		}
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
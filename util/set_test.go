@@ -0,0 +1,82 @@
+package util
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSet(t *testing.T) {
+	s := NewSet(3, 1, 2)
+	if s.Len() != 3 || !s.Has(1) || s.Has(4) {
+		t.Errorf("NewSet(3, 1, 2) = %v", s)
+	}
+	s.Add(4, 4)
+	s.Remove(1, 5)
+	if want := []int{2, 3, 4}; !reflect.DeepEqual(s.Values(), want) {
+		t.Errorf("got %v, want %v", s.Values(), want)
+	}
+
+	clone := s.Clone()
+	clone.Add(10)
+	if s.Has(10) {
+		t.Error("adding to a clone changed the set")
+	}
+}
+
+func TestSetAlgebra(t *testing.T) {
+	a := NewSet(1, 2, 3, 4)
+	b := NewSet(3, 4, 5)
+	tests := []struct {
+		name string
+		got  Set[int]
+		want Set[int]
+	}{
+		{"Union", a.Union(b), NewSet(1, 2, 3, 4, 5)},
+		{"Intersection", a.Intersection(b), NewSet(3, 4)},
+		{"Difference", a.Difference(b), NewSet(1, 2)},
+		{"Difference reversed", b.Difference(a), NewSet(5)},
+		{"Intersection empty", a.Intersection(NewSet[int]()), NewSet[int]()},
+	}
+	for _, test := range tests {
+		if !test.got.Equal(test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, test.got, test.want)
+		}
+	}
+	if a.Len() != 4 || b.Len() != 3 {
+		t.Errorf("operations changed their operands to %v and %v", a, b)
+	}
+
+	if !NewSet(3, 4).IsSubset(a) || b.IsSubset(a) ||
+		!NewSet[int]().IsSubset(a) || !a.IsSubset(a) {
+		t.Error("IsSubset is wrong")
+	}
+	if a.Equal(b) || !a.Equal(a.Clone()) {
+		t.Error("Equal is wrong")
+	}
+}
+
+func TestSetString(t *testing.T) {
+	tests := []struct {
+		got  string
+		want string
+	}{
+		{NewSet[int]().String(), "{}"},
+		{NewSet(10, 2, -1).String(), "{-1, 2, 10}"},
+		{NewSet("b", "a").String(), "{a, b}"},
+		{NewSet[byte]('b', 'a').String(), "{97, 98}"},
+		{NewSet(Point{1, 2}, Point{0, 5}).String(), "{{0 5}, {1 2}}"},
+	}
+	for _, test := range tests {
+		if test.got != test.want {
+			t.Errorf("got %q, want %q", test.got, test.want)
+		}
+	}
+
+	var visited []string
+	NewSet("c", "a", "b").Do(func(value string) {
+		visited = append(visited, value)
+	})
+	if want := []string{"a", "b", "c"}; !reflect.DeepEqual(visited, want) {
+		t.Errorf("Do visited %v, want %v", visited, want)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/fritzr/advent2020/util/numtheory"
 )
 
 func ReadLines(input io.Reader) ([]string, error) {
@@ -28,16 +30,20 @@ func ReadLinesFromFile(path string) ([]string, error) {
 	return ReadLines(file)
 }
 
+// Read whitespace-separated integers. An invalid number is reported as a
+// ParseError.
 func ReadNumbers(input io.Reader) ([]int, error) {
 	scanner := bufio.NewScanner(input)
-	scanner.Split(bufio.ScanWords)
+	scanner.Split(bufio.ScanLines)
 	data := make([]int, 0, 1024)
-	for scanner.Scan() {
-		value, err := strconv.Atoi(scanner.Text())
-		if err != nil {
-			return data, err
+	for line := 1; scanner.Scan(); line++ {
+		for _, field := range FieldsAt(scanner.Text()) {
+			value, err := strconv.Atoi(field.Text)
+			if err != nil {
+				return data, NewParseError(line, field.Offset+1, field.Text, err)
+			}
+			data = append(data, value)
 		}
-		data = append(data, value)
 	}
 	return data, scanner.Err()
 }
@@ -57,79 +63,6 @@ func PrintArray(array []int) {
 	}
 }
 
-type Set map[int]bool
-
-func (s Set) String() string {
-	var ss strings.Builder
-	ss.WriteRune('{')
-	count := 0
-	for value, _ := range s {
-		ss.WriteString(strconv.Itoa(value))
-		if count != len(s)-1 {
-			ss.WriteString(", ")
-		}
-		count++
-	}
-	ss.WriteRune('}')
-	return ss.String()
-}
-
-func first_not_of(haystack []byte, hay byte) int {
-	for idx, c := range haystack {
-		if c != hay {
-			return idx // needle
-		}
-	}
-	return -1
-}
-
-// SplitFunc for a bufio.Scanner which splits input into groups of lines
-// separated by groups of blank lines.
-func ScanLineGroups(data []byte, atEOF bool) (advance int, token []byte, err error) {
-	var (
-		consecutive int
-		start       int
-		end         int
-		c           byte
-	)
-
-	// Capture until we see consecutive empty lines.
-	for advance, c = range data {
-		if c == '\n' {
-			if consecutive == 0 {
-				end = advance
-			}
-			consecutive++
-		} else {
-			if consecutive > 1 {
-				break
-			}
-			consecutive = 0
-			end = start
-		}
-	}
-
-	// Didn't find a complete token, expand the buffer.
-	if consecutive < 2 && !atEOF {
-		return 0, nil, nil
-	}
-
-	// Found a token (maybe).
-	if end > start {
-		token = data[start:end]
-	}
-
-	// Eat trailing newlines.
-	next := first_not_of(data[advance:], '\n')
-	if next < 0 {
-		advance = len(data)
-	} else {
-		advance += next
-	}
-
-	return advance, token, err
-}
-
 func ScanInput(input io.Reader, scan bufio.SplitFunc) ([]string, error) {
 	scanner := bufio.NewScanner(input)
 	scanner.Split(scan)
@@ -140,8 +73,26 @@ func ScanInput(input io.Reader, scan bufio.SplitFunc) ([]string, error) {
 	return result, scanner.Err()
 }
 
+// Read all of the groups of lines separated by blank lines; see
+// LineGroupScanner.
 func ReadLineGroups(input io.Reader) ([]string, error) {
-	return ScanInput(input, ScanLineGroups)
+	groups, err := ReadNumberedLineGroups(input)
+	texts := make([]string, len(groups))
+	for index, group := range groups {
+		texts[index] = group.Text
+	}
+	return texts, err
+}
+
+// Like ReadLineGroups, but also returns where each group starts, for use
+// with AtLine.
+func ReadNumberedLineGroups(input io.Reader) ([]LineGroup, error) {
+	scanner := NewLineGroupScanner(input)
+	groups := make([]LineGroup, 0)
+	for scanner.Scan() {
+		groups = append(groups, scanner.Group())
+	}
+	return groups, scanner.Err()
 }
 
 func ReadLineGroupsFromFile(path string) ([]string, error) {
@@ -164,15 +115,17 @@ func ReadFile(path string, read func(input io.Reader) (interface{}, error)) (
 	return read(file)
 }
 
+// Convert each string to an integer. An invalid one is reported as a
+// ParseError, without a position.
 func FieldsToInts(strings []string) (ints []int, err error) {
 	ints = make([]int, len(strings))
 	for index, str := range strings {
 		ints[index], err = strconv.Atoi(str)
 		if err != nil {
-			break
+			return ints, NewParseError(0, 0, str, err)
 		}
 	}
-	return ints, err
+	return ints, nil
 }
 
 func Product(numbers []int) int {
@@ -183,12 +136,10 @@ func Product(numbers []int) int {
 	return result
 }
 
+// IPow returns root to the power exp, which must not be negative; see
+// numtheory.Pow.
 func IPow(root int, exp int) int {
-	root = 1
-	for n := 0; n < exp; n++ {
-		root *= exp
-	}
-	return root
+	return int(numtheory.Pow(int64(root), exp))
 }
 
 func IAbs(i int) int {
@@ -248,106 +199,3 @@ func Rotate(index int, by int, length int) int {
 	}
 	return index
 }
-
-// Like a ring.Ring, but uses an array buffer rather than a linked list.
-type RingBuffer struct {
-	_data []interface{}
-
-	// Points to the tail, i.e. the last (most recently inserted) element.
-	//
-	// The head, i.e. first (least recently inserted) is at (_tail + 1) % Len().
-	_tail int
-}
-
-func NewRingBuffer(size int) *RingBuffer {
-	r := new(RingBuffer)
-	r._data = make([]interface{}, size)
-	r._tail = size - 1
-	return r
-}
-
-func (r *RingBuffer) rotated(n int) int {
-	return Rotate(r._tail, n, r.Len())
-}
-
-func (r *RingBuffer) head() int {
-	return r.rotated(1)
-}
-
-func (r *RingBuffer) tail() int {
-	return r._tail
-}
-
-// Push a new value.
-//
-// Returns the least-recently inserted value which was overwritten.
-func (r *RingBuffer) Push(value interface{}) interface{} {
-	r.Move(1)
-	tail := r.tail()
-	lru := r._data[tail]
-	r._data[tail] = value
-	return lru
-}
-
-// Pop the most-recently inserted value.
-func (r *RingBuffer) Pop() interface{} {
-	result := r._data[r.tail()]
-	r.Move(-1)
-	return result
-}
-
-// Return the most recently inserted (last) element.
-func (r *RingBuffer) Last() interface{} {
-	return r._data[r.tail()]
-}
-
-// Return the least recently inserted (first) element.
-func (r *RingBuffer) First() interface{} {
-	return r._data[r.head()]
-}
-
-func (r *RingBuffer) Len() int {
-	return len(r._data)
-}
-
-// Move n % r.Len() elements backward (n < 0) or forward (n >= 0).
-func (r *RingBuffer) Move(n int) *RingBuffer {
-	r._tail = r.rotated(n)
-	return r
-}
-
-// Get the n-th least recently used element.
-//
-// For example, Get(0) === First(),
-// and Get(-1) === Get(Len()-1) === Last().
-func (r *RingBuffer) Get(n int) interface{} {
-	return r._data[Rotate(r.head(), n, r.Len())]
-}
-
-// Get the n-th most recently used element.
-//
-// For example, GetLast(0) == Last(),
-// and Getlast(-1) === GetLast(Len()-1) === First().
-func (r *RingBuffer) GetLast(n int) interface{} {
-	return r._data[Rotate(r.tail(), -n, r.Len())]
-}
-
-// Call f on each element of the ring in forward order.
-func (r *RingBuffer) Do(f func(interface{})) {
-	// Do the head, then wrap around until we reach head again.
-	head := r.head()
-	f(r._data[head])
-	for idx := r.rotated(2); idx != head; idx++ {
-		f(r._data[idx])
-	}
-}
-
-// Call f on each element of the ring in forward order so long as f returns true
-func (r *RingBuffer) DoWhile(f func(interface{}) bool) {
-	// Do the head, then wrap around until we reach head again.
-	head := r.head()
-	if f(r._data[head]) {
-		for idx := r.rotated(2); idx != head && f(r._data[idx]); idx++ {
-		}
-	}
-}
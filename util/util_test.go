@@ -0,0 +1,340 @@
+package util
+
+import (
+	"bufio"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestReadLineGroups(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []string
+	}{
+		{"empty", "", []string{}},
+		{"blank", "\n\n", []string{}},
+		{"one line", "a\n", []string{"a"}},
+		{"no final newline", "a\n\nb", []string{"a", "b"}},
+		{"multi-line groups", "a\nb\n\nc\nd\n", []string{"a\nb", "c\nd"}},
+		{"several blank lines", "a\n\n\n\nb\n", []string{"a", "b"}},
+		{"leading blank lines", "\n\na\n", []string{"a"}},
+		{"trailing blank lines", "a\n\nb\n\n\n", []string{"a", "b"}},
+		{"CRLF", "a\r\nb\r\n\r\nc\r\n", []string{"a\nb", "c"}},
+		{"whitespace-only lines", "a\n \t\nb\n  \n", []string{"a", "b"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := ReadLineGroups(strings.NewReader(test.input))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+
+			// The scanner must also cope with input arriving in small pieces.
+			got, err = ReadLineGroups(
+				iotest.OneByteReader(strings.NewReader(test.input)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("one byte at a time: got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestLineGroupScanner(t *testing.T) {
+	input := "\r\nfirst\r\ngroup\r\n \r\n\r\nsecond\n\nthird\n  \n"
+	want := []LineGroup{{"first\ngroup", 2}, {"second", 6}, {"third", 8}}
+	scanner := NewLineGroupScanner(
+		iotest.OneByteReader(strings.NewReader(input)))
+	var got []LineGroup
+	for scanner.Scan() {
+		if scanner.Count() != len(got)+1 {
+			t.Errorf("Count() = %d during group %d", scanner.Count(),
+				len(got)+1)
+		}
+		got = append(got, scanner.Group())
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if scanner.Scan() || scanner.Count() != len(want) {
+		t.Errorf("Scan() after the end found group %d", scanner.Count())
+	}
+
+	scanner = NewLineGroupScanner(iotest.TimeoutReader(
+		iotest.OneByteReader(strings.NewReader("a\nb\n"))))
+	if scanner.Scan() || scanner.Err() != iotest.ErrTimeout {
+		t.Errorf("got %q, %v, want an error", scanner.Text(), scanner.Err())
+	}
+}
+
+func TestRotate(t *testing.T) {
+	tests := []struct {
+		index, by, length int
+		want              int
+	}{
+		{0, 0, 5, 0},
+		{1, 2, 5, 3},
+		{3, 2, 5, 0},
+		{4, 13, 5, 2},
+		{2, -1, 5, 1},
+		{0, -1, 5, 4},
+		{1, -12, 5, 4},
+		{0, 5, 5, 0},
+		{0, -5, 5, 0},
+	}
+	for _, test := range tests {
+		got := Rotate(test.index, test.by, test.length)
+		if got != test.want {
+			t.Errorf("Rotate(%d, %d, %d) = %d, want %d",
+				test.index, test.by, test.length, got, test.want)
+		}
+	}
+}
+
+func TestRingBuffer(t *testing.T) {
+	r := NewRingBuffer[int](3)
+	if r.Len() != 0 || r.Cap() != 3 {
+		t.Fatalf("Len(), Cap() = %d, %d, want 0, 3", r.Len(), r.Cap())
+	}
+	for value := 1; value <= 3; value++ {
+		if lru, ok := r.Push(value); ok {
+			t.Errorf("Push(%d) overwrote %v in an unfilled buffer", value, lru)
+		}
+		if r.Len() != value {
+			t.Errorf("Len() = %d after %d pushes", r.Len(), value)
+		}
+	}
+	if lru, ok := r.Push(4); !ok || lru != 1 {
+		t.Errorf("Push(4) overwrote %v, %v, want 1, true", lru, ok)
+	}
+	if r.Len() != 3 || !r.Full() {
+		t.Errorf("Len() = %d, Full() = %v, want 3, true", r.Len(), r.Full())
+	}
+
+	// The buffer now holds 2, 3, 4 from least to most recently pushed.
+	if first := r.First(); first != 2 {
+		t.Errorf("First() = %v, want 2", first)
+	}
+	if last := r.Last(); last != 4 {
+		t.Errorf("Last() = %v, want 4", last)
+	}
+	for n, want := range []int{2, 3, 4} {
+		if got := r.Get(n); got != want {
+			t.Errorf("Get(%d) = %v, want %d", n, got, want)
+		}
+		if got := r.GetLast(n); got != 4-n {
+			t.Errorf("GetLast(%d) = %v, want %d", n, got, 4-n)
+		}
+	}
+	if got := r.Get(-1); got != 4 {
+		t.Errorf("Get(-1) = %v, want 4", got)
+	}
+	if got := r.GetLast(-1); got != 2 {
+		t.Errorf("GetLast(-1) = %v, want 2", got)
+	}
+
+	if popped, ok := r.Pop(); !ok || popped != 4 {
+		t.Errorf("Pop() = %v, %v, want 4, true", popped, ok)
+	}
+	if last := r.Last(); last != 3 {
+		t.Errorf("Last() after Pop() = %v, want 3", last)
+	}
+	if r.Len() != 2 || r.Full() {
+		t.Errorf("Len() = %d, Full() = %v after Pop(), want 2, false",
+			r.Len(), r.Full())
+	}
+	if shifted, ok := r.Shift(); !ok || shifted != 2 {
+		t.Errorf("Shift() = %v, %v, want 2, true", shifted, ok)
+	}
+	r.Pop()
+	if _, ok := r.Pop(); ok {
+		t.Error("Pop() from an empty buffer succeeded")
+	}
+	if _, ok := r.Shift(); ok {
+		t.Error("Shift() from an empty buffer succeeded")
+	}
+}
+
+func TestRingBufferDo(t *testing.T) {
+	// Wrap around the end of the slice: the head is in the middle.
+	r := NewRingBuffer[int](4)
+	for value := 1; value <= 6; value++ {
+		r.Push(value)
+	}
+	var forward, backward []int
+	r.Do(func(n int, value int) {
+		if value != r.Get(n) {
+			t.Errorf("Do: value %d at %d, want %d", value, n, r.Get(n))
+		}
+		forward = append(forward, value)
+	})
+	r.DoReverse(func(n int, value int) {
+		if value != r.GetLast(n) {
+			t.Errorf("DoReverse: value %d at %d, want %d", value, n,
+				r.GetLast(n))
+		}
+		backward = append(backward, value)
+	})
+	if want := []int{3, 4, 5, 6}; !reflect.DeepEqual(forward, want) {
+		t.Errorf("Do visited %v, want %v", forward, want)
+	}
+	if want := []int{6, 5, 4, 3}; !reflect.DeepEqual(backward, want) {
+		t.Errorf("DoReverse visited %v, want %v", backward, want)
+	}
+
+	var visited []int
+	done := r.DoWhile(func(n int, value int) bool {
+		visited = append(visited, value)
+		return value < 5
+	})
+	if want := []int{3, 4, 5}; done || !reflect.DeepEqual(visited, want) {
+		t.Errorf("DoWhile visited %v, %v, want %v, false", visited, done, want)
+	}
+	visited = nil
+	done = r.DoReverseWhile(func(n int, value int) bool {
+		visited = append(visited, value)
+		return true
+	})
+	if want := []int{6, 5, 4, 3}; !done || !reflect.DeepEqual(visited, want) {
+		t.Errorf("DoReverseWhile visited %v, %v, want %v, true", visited, done,
+			want)
+	}
+
+	// Nothing is visited in an empty buffer.
+	NewRingBuffer[int](2).Do(func(n int, value int) {
+		t.Errorf("Do visited %d in an empty buffer", value)
+	})
+}
+
+func TestRingBufferGrow(t *testing.T) {
+	r := NewRingBuffer[string](2)
+	r.Push("a")
+	r.Push("b")
+	r.Push("c") // overwrites "a", so the head is not at the start
+	r.Append("d")
+	r.Append("e")
+	if want := []string{"b", "c", "d", "e"}; !reflect.DeepEqual(r.Slice(), want) {
+		t.Errorf("got %q, want %q", r.Slice(), want)
+	}
+	if r.Cap() < 4 {
+		t.Errorf("Cap() = %d, want at least 4", r.Cap())
+	}
+	r.Grow(10)
+	if r.Cap() < 14 || r.Len() != 4 {
+		t.Errorf("Len(), Cap() = %d, %d after Grow(10)", r.Len(), r.Cap())
+	}
+
+	r.Move(1)
+	if want := []string{"c", "d", "e", "b"}; !reflect.DeepEqual(r.Slice(), want) {
+		t.Errorf("Move(1): got %q, want %q", r.Slice(), want)
+	}
+	r.Move(-2)
+	if want := []string{"e", "b", "c", "d"}; !reflect.DeepEqual(r.Slice(), want) {
+		t.Errorf("Move(-2): got %q, want %q", r.Slice(), want)
+	}
+
+	var empty RingBuffer[int]
+	if _, ok := empty.Push(1); !ok || empty.Len() != 0 {
+		t.Error("Push() to a buffer without capacity kept the value")
+	}
+	empty.Append(1)
+	if empty.Len() != 1 || empty.First() != 1 {
+		t.Errorf("Append() to a buffer without capacity: %v", empty.Slice())
+	}
+}
+
+func TestFieldsToInts(t *testing.T) {
+	got, err := FieldsToInts([]string{"1", "-2", "30"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{1, -2, 30}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+	if _, err = FieldsToInts([]string{"1", "x"}); err == nil {
+		t.Error("expected an error for a non-numeric field")
+	}
+}
+
+func TestIMinIMax(t *testing.T) {
+	numbers := []int{5, -3, 9, 0, 9}
+	if index, min := IMin(numbers); index != 1 || min != -3 {
+		t.Errorf("IMin() = %d, %d, want 1, -3", index, min)
+	}
+	if index, max := IMax(numbers); index != 2 || max != 9 {
+		t.Errorf("IMax() = %d, %d, want 2, 9", index, max)
+	}
+}
+
+func TestIPow(t *testing.T) {
+	tests := []struct{ root, exp, want int }{
+		{2, 0, 1},
+		{2, 10, 1024},
+		{-3, 3, -27},
+		{7, 1, 7},
+	}
+	for _, test := range tests {
+		if got := IPow(test.root, test.exp); got != test.want {
+			t.Errorf("IPow(%d, %d) = %d, want %d", test.root, test.exp, got,
+				test.want)
+		}
+	}
+}
+
+func FuzzReadNumbers(f *testing.F) {
+	for _, seed := range []string{"", "1 2\n-3\n", "1\r\n2", "1 x", "99999999999999999999"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		numbers, err := ReadNumbers(strings.NewReader(data))
+		var parseErr *ParseError
+		if err != nil && !errors.As(err, &parseErr) {
+			// Only a line too long for the scanner is not a ParseError.
+			if !errors.Is(err, bufio.ErrTooLong) {
+				t.Errorf("got %T %v, want a ParseError", err, err)
+			}
+		}
+		if err == nil && len(numbers) != len(strings.Fields(data)) {
+			t.Errorf("read %d numbers from %d fields", len(numbers),
+				len(strings.Fields(data)))
+		}
+	})
+}
+
+func FuzzLineGroupScanner(f *testing.F) {
+	for _, seed := range []string{"", "a\nb\n\nc\n", "\n\n\na", "a\r\n \r\nb", "a\n\t\n"} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		groups := NewLineGroupScanner(strings.NewReader(data))
+		lines := strings.Count(data, "\n") + 1
+		last := 0
+		for groups.Scan() {
+			group := groups.Group()
+			if group.Line <= last || group.Line > lines {
+				t.Errorf("group %d starts on line %d after line %d of %d",
+					groups.Count(), group.Line, last, lines)
+			}
+			if strings.TrimSpace(group.Text) == "" {
+				t.Errorf("group %d on line %d is blank", groups.Count(),
+					group.Line)
+			}
+			last = group.Line + strings.Count(group.Text, "\n")
+		}
+		if err := groups.Err(); err != nil && !errors.Is(err, bufio.ErrTooLong) {
+			t.Error(err)
+		}
+	})
+}